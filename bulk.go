@@ -0,0 +1,157 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// prefetchKey carries the resources pulled ahead of Start loading them
+type prefetchKey struct{}
+
+func prefetched(ctx context.Context, res *opts.ResourceOption) (rawResource, bool) {
+	raws, _ := ctx.Value(prefetchKey{}).(map[*opts.ResourceOption]rawResource)
+	raw, ok := raws[res]
+	return raw, ok
+}
+
+// prefetchBulk pulls the resources in one request to the bulk endpoint. The ones
+// the bulk endpoint did not return, or all of them when it is not served, are
+// left to be pulled on their own.
+func (c *Consumer[C, S]) prefetchBulk(ctx context.Context, resources []*opts.ResourceOption) map[*opts.ResourceOption]rawResource {
+	bulk, err := c.fetchBulk(ctx, resources)
+	if err != nil {
+		c.log().Info("sailor bulk fetch unavailable, pulling resources one by one", slog.Any("error", err))
+		return nil
+	}
+
+	raws := make(map[*opts.ResourceOption]rawResource, len(resources))
+	for _, res := range resources {
+		if raw, ok := bulk[resourceKey{res.Def.Kind, res.Def.Name}]; ok {
+			raws[res] = raw
+		}
+	}
+
+	return raws
+}
+
+// fetchBulk requests the resources from the bulk endpoint of the app and returns
+// the ones the envelope carried
+func (c *Consumer[C, S]) fetchBulk(ctx context.Context, resources []*opts.ResourceOption) (map[resourceKey]rawResource, error) {
+	started := time.Now()
+	resp, err := c.doGet(ctx, c.bulkURL(resources))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bulk endpoint: %w", c.statusError(resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var state opts.SailorBulkState
+	if err := json.Unmarshal(body, &state); err != nil {
+		return nil, fmt.Errorf("sailor bulk response is malformed: %w", err)
+	}
+
+	pinned := map[resourceKey]string{}
+	for _, res := range resources {
+		if res.Def.Version != "" {
+			pinned[resourceKey{res.Def.Kind, res.Def.Name}] = res.Def.Version
+		}
+	}
+
+	raws := map[resourceKey]rawResource{}
+	add := func(kind opts.ResourceKind, name string, bulkRes opts.SailorBulkResource) {
+		// an unsigned or badly signed resource is fetched on its own, which
+		// reports why it is rejected
+		if c.verifyPayload(bulkRes.Data, bulkRes.Signature) != nil {
+			return
+		}
+		version := bulkRes.Version
+		if want, ok := pinned[resourceKey{kind, name}]; ok {
+			// another version is left out for the resource to be fetched on its
+			// own, which reports why the pinned one cannot be served
+			if version != "" && version != want {
+				return
+			}
+			version = want
+		}
+		if version == "" {
+			version = resourceVersion(nil, bulkRes.Data)
+		}
+		raws[resourceKey{kind, name}] = rawResource{
+			kind:       kind,
+			name:       name,
+			source:     SourcePull,
+			statusCode: resp.StatusCode,
+			version:    version,
+			started:    started,
+			data:       bulkRes.Data,
+			endpoint:   c.endpoints.endpointFor(resp.Request.URL),
+		}
+	}
+
+	if state.Config != nil {
+		add(opts.CONFIGS, "", *state.Config)
+	}
+	if state.Secrets != nil {
+		add(opts.SECRETS, "", *state.Secrets)
+	}
+	for name, bulkRes := range state.Misc {
+		add(opts.MISC, name, bulkRes)
+	}
+
+	return raws, nil
+}
+
+// bulkURL is the Sailor API endpoint serving the given resources of the app at
+// once, each of them named by a resource query parameter: config, secret or
+// misc/{name}, followed by @{version} when pinned to a version
+func (c *Consumer[C, S]) bulkURL(resources []*opts.ResourceOption) string {
+	query := url.Values{}
+	for _, res := range resources {
+		name := string(res.Def.Kind)
+		if res.Def.Kind == opts.MISC {
+			name += "/" + res.Def.Name
+		}
+		if res.Def.Version != "" {
+			name += "@" + res.Def.Version
+		}
+		query.Add("resource", name)
+	}
+
+	return fmt.Sprintf("%s/api/v1/bulk/%s/%s?%s",
+		c.opts.Connection.Addr,
+		c.opts.Connection.Namespace,
+		c.opts.Connection.App,
+		query.Encode(),
+	)
+}
@@ -0,0 +1,140 @@
+package sailor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+// bulkTestResources are pulled together through the bulk endpoint
+func bulkTestResources() []opts.ResourceOption {
+	resources := []opts.ResourceOption{configPullOnce()}
+	for _, def := range []opts.ResourceDefinition{
+		{Kind: opts.SECRETS},
+		{Kind: opts.MISC, Name: "certs"},
+		{Kind: opts.MISC, Name: "rules"},
+	} {
+		resources = append(resources, opts.ResourceOption{
+			Def:      def,
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+		})
+	}
+	return resources
+}
+
+func assertBulkTestValues(t *testing.T, consumer *Consumer[map[string]string, map[string]string]) {
+	t.Helper()
+
+	if config, _ := consumer.Get(); config["app"] != "test" {
+		t.Errorf("unexpected config %v", config)
+	}
+	if secrets, _ := consumer.GetSecret(); secrets["password"] != "secret" {
+		t.Errorf("unexpected secrets %v", secrets)
+	}
+	for _, name := range []string{"certs", "rules"} {
+		if misc, _ := consumer.GetMisc(name); string(misc) != name {
+			t.Errorf("expected %s got %s", name, misc)
+		}
+	}
+}
+
+func setBulkTestValues(t *testing.T, server *sailortest.Server) {
+	t.Helper()
+
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+	if err := server.SetSecrets("test", "test", "ak", "sk", map[string]string{"password": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetMisc("test", "test", "certs", []byte("certs"))
+	server.SetMisc("test", "test", "rules", []byte("rules"))
+}
+
+func TestBulkFetch(t *testing.T) {
+	server := sailortest.NewServer(t)
+	setBulkTestValues(t, server)
+
+	consumer := newTestConsumer[map[string]string, map[string]string](t, opts.InitOption{
+		Resources:  bulkTestResources(),
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertBulkTestValues(t, consumer)
+
+	if n := server.Requests("/api/v1/bulk/test/test"); n != 1 {
+		t.Errorf("expected a single bulk request got %d", n)
+	}
+	for _, path := range []string{"config", "secret", "misc/certs", "misc/rules"} {
+		if n := server.Requests("/api/v1/resource/test/test/" + path); n != 0 {
+			t.Errorf("expected no request for %s got %d", path, n)
+		}
+	}
+
+	status := consumer.Status()
+	for _, rs := range status.Resources {
+		if rs.Source != SourcePull || rs.Version != "v1" {
+			t.Errorf("unexpected status %+v", rs)
+		}
+	}
+}
+
+func TestBulkFetchUnsupported(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetBulk(false)
+	server.SetLatency(50 * time.Millisecond)
+	setBulkTestValues(t, server)
+
+	started := time.Now()
+	consumer := newTestConsumer[map[string]string, map[string]string](t, opts.InitOption{
+		Resources:  bulkTestResources(),
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertBulkTestValues(t, consumer)
+
+	// the bulk attempt and the four resources pulled in parallel
+	if took := time.Since(started); took > 200*time.Millisecond {
+		t.Errorf("expected parallel fetches, start took %s", took)
+	}
+	for _, path := range []string{"config", "secret", "misc/certs", "misc/rules"} {
+		if n := server.Requests("/api/v1/resource/test/test/" + path); n != 1 {
+			t.Errorf("expected a single request for %s got %d", path, n)
+		}
+	}
+}
+
+func TestBulkFetchPartial(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+	if err := server.SetSecrets("test", "test", "ak", "sk", map[string]string{"password": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetMisc("test", "test", "certs", []byte("certs"))
+
+	consumer := newTestConsumer[map[string]string, map[string]string](t, opts.InitOption{
+		Resources: []opts.ResourceOption{
+			configPullOnce(),
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "rules"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+
+	// rules is missing from the envelope, it is pulled on its own and fails
+	if err := consumer.Start(); err == nil {
+		t.Error("expected start to fail for the missing resource")
+	}
+	if n := server.Requests("/api/v1/resource/test/test/misc/rules"); n != 1 {
+		t.Errorf("expected the missing resource to be pulled, got %d requests", n)
+	}
+	if n := server.Requests("/api/v1/resource/test/test/config"); n != 0 {
+		t.Errorf("expected config to come from the bulk endpoint, got %d requests", n)
+	}
+}
@@ -0,0 +1,231 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeout      = 30 * time.Second
+	defaultCircuitHalfOpenRequests = 1
+)
+
+// CircuitState is the state of the circuit breaker around the Sailor API
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen fails every request right away
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a few probe requests through to find out if Sailor
+	// is back
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitStatus is the state of the circuit breaker at a point in time
+type CircuitStatus struct {
+	State               CircuitState `json:"state"`
+	Since               time.Time    `json:"since"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+}
+
+// circuitBreaker counts the consecutive failures of the requests to Sailor and
+// opens after FailureThreshold of them, failing requests without sending them
+// until OpenTimeout is over. Then HalfOpenRequests probes are let through, the
+// circuit closes when one succeeds and opens again when one fails.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	threshold int
+	openFor   time.Duration
+	probes    int
+
+	state    CircuitState
+	since    time.Time
+	failures int
+	inFlight int
+
+	// changed is called outside the lock on every change of state
+	changed func(from, to CircuitState, at time.Time)
+}
+
+// newCircuitBreaker returns nil when the circuit breaker is disabled
+func newCircuitBreaker(opt *opts.CircuitBreakerOption, changed func(from, to CircuitState, at time.Time)) *circuitBreaker {
+	if opt == nil {
+		return nil
+	}
+
+	b := &circuitBreaker{
+		threshold: opt.FailureThreshold,
+		openFor:   opt.OpenTimeout,
+		probes:    opt.HalfOpenRequests,
+		state:     CircuitClosed,
+		since:     time.Now(),
+		changed:   changed,
+	}
+	if b.threshold <= 0 {
+		b.threshold = defaultCircuitFailureThreshold
+	}
+	if b.openFor <= 0 {
+		b.openFor = defaultCircuitOpenTimeout
+	}
+	if b.probes <= 0 {
+		b.probes = defaultCircuitHalfOpenRequests
+	}
+	return b
+}
+
+// allow tells if a request may be sent, probe is true for the requests let
+// through while half-open. Every allowed request must be followed by done.
+func (b *circuitBreaker) allow(now time.Time) (allowed, probe bool) {
+	if b == nil {
+		return true, false
+	}
+
+	b.mu.Lock()
+	var from CircuitState
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.since) < b.openFor {
+			b.mu.Unlock()
+			return false, false
+		}
+		from = b.setState(CircuitHalfOpen, now)
+		fallthrough
+	case CircuitHalfOpen:
+		allowed = b.inFlight < b.probes
+		if allowed {
+			b.inFlight++
+		}
+		probe = allowed
+	default:
+		allowed = true
+	}
+	b.mu.Unlock()
+
+	b.notify(from, CircuitHalfOpen, now)
+	return allowed, probe
+}
+
+// done records the outcome of an allowed request, ignored is for requests which
+// say nothing about Sailor like the ones canceled by the caller
+func (b *circuitBreaker) done(probe, failed, ignored bool, now time.Time) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	var from, to CircuitState
+	if probe {
+		b.inFlight--
+	}
+	switch {
+	case ignored:
+	case b.state == CircuitClosed:
+		if !failed {
+			b.failures = 0
+			break
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			from, to = b.setState(CircuitOpen, now), CircuitOpen
+		}
+	case b.state == CircuitHalfOpen && probe:
+		if failed {
+			from, to = b.setState(CircuitOpen, now), CircuitOpen
+		} else {
+			b.failures = 0
+			from, to = b.setState(CircuitClosed, now), CircuitClosed
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to, now)
+}
+
+// setState moves to the given state and returns the previous one, empty when
+// it did not change. Callers hold the lock.
+func (b *circuitBreaker) setState(to CircuitState, now time.Time) CircuitState {
+	if b.state == to {
+		return ""
+	}
+	from := b.state
+	b.state = to
+	b.since = now
+	return from
+}
+
+func (b *circuitBreaker) notify(from, to CircuitState, at time.Time) {
+	if from != "" && b.changed != nil {
+		b.changed(from, to, at)
+	}
+}
+
+func (b *circuitBreaker) status() *CircuitStatus {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &CircuitStatus{State: b.state, Since: b.since, ConsecutiveFailures: b.failures}
+}
+
+// circuitChanged logs the new state of the circuit and reports it to metrics
+func (c *Consumer[C, S]) circuitChanged(from, to CircuitState, at time.Time) {
+	c.reportCircuit(to, at)
+
+	level := slog.LevelInfo
+	if to == CircuitOpen {
+		level = slog.LevelWarn
+	}
+	c.log().Log(context.Background(), level, "sailor circuit breaker changed state",
+		slog.String("from", string(from)),
+		slog.String("to", string(to)),
+	)
+}
+
+// reportCircuit hands the state of the circuit to metrics which follow it
+func (c *Consumer[C, S]) reportCircuit(state CircuitState, at time.Time) {
+	if cm, ok := c.metrics.(metrics.CircuitMetrics); ok {
+		cm.CircuitState(string(state), at)
+	}
+}
+
+// sendThroughCircuit sends the request unless the circuit is open and records
+// how Sailor answered
+func (c *Consumer[C, S]) sendThroughCircuit(req *http.Request) (*http.Response, error) {
+	allowed, probe := c.breaker.allow(time.Now())
+	if !allowed {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := c.roundTrip(req)
+	ignored := req.Context().Err() != nil || errors.Is(err, ErrCircuitOpen)
+	c.breaker.done(probe, isEndpointFailure(resp, err), ignored, time.Now())
+	return resp, err
+}
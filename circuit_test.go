@@ -0,0 +1,161 @@
+package sailor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	var changes []CircuitState
+	b := newCircuitBreaker(&opts.CircuitBreakerOption{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenRequests: 1,
+	}, func(_, to CircuitState, _ time.Time) { changes = append(changes, to) })
+	now := time.Now()
+
+	fail := func() {
+		_, probe := b.allow(now)
+		b.done(probe, true, false, now)
+	}
+
+	// a success in between resets the count
+	fail()
+	b.allow(now)
+	b.done(false, false, false, now)
+	fail()
+	if b.status().State != CircuitClosed {
+		t.Fatalf("expected the circuit to stay closed, got %s", b.status().State)
+	}
+
+	fail()
+	if allowed, _ := b.allow(now); allowed || b.status().State != CircuitOpen {
+		t.Fatalf("expected the open circuit to reject requests")
+	}
+
+	// one probe at a time once the timeout is over, a failed probe opens again
+	now = now.Add(time.Minute)
+	allowed, probe := b.allow(now)
+	if !allowed || !probe || b.status().State != CircuitHalfOpen {
+		t.Fatalf("expected a probe to be let through")
+	}
+	if allowed, _ := b.allow(now); allowed {
+		t.Errorf("expected a single probe in flight")
+	}
+	b.done(true, true, false, now)
+	if b.status().State != CircuitOpen {
+		t.Fatalf("expected a failed probe to open the circuit, got %s", b.status().State)
+	}
+
+	now = now.Add(time.Minute)
+	_, probe = b.allow(now)
+	b.done(probe, false, false, now)
+	if st := b.status(); st.State != CircuitClosed || st.ConsecutiveFailures != 0 {
+		t.Fatalf("expected a successful probe to close the circuit, got %+v", st)
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("expected changes %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("expected changes %v, got %v", want, changes)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledRequests(t *testing.T) {
+	b := newCircuitBreaker(&opts.CircuitBreakerOption{FailureThreshold: 1}, nil)
+	_, probe := b.allow(time.Now())
+	b.done(probe, true, true, time.Now())
+	if b.status().State != CircuitClosed {
+		t.Errorf("expected a canceled request not to open the circuit")
+	}
+}
+
+func TestCircuitOpenServesFallback(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"from": "sailor"})
+	server.SetFallback("test", opts.CONFIGS, []byte(`{"from": "fallback"}`))
+	server.SetStatus(503)
+
+	registry := metrics.NewRegistry()
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:      []opts.ResourceOption{configPullOnce()},
+		Connection:     fallbackConn(server),
+		Metrics:        registry,
+		CircuitBreaker: &opts.CircuitBreakerOption{FailureThreshold: 2, OpenTimeout: time.Hour},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if st := consumer.Status().Circuit; st == nil || st.State != CircuitOpen {
+		t.Fatalf("expected the circuit to be open, got %+v", st)
+	}
+	if snap := registry.Circuit(); snap.State != "open" || snap.Opened != 1 {
+		t.Errorf("expected metrics to report the open circuit, got %+v", snap)
+	}
+
+	before := server.Requests(endpointsTestConfigPath)
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Requests(endpointsTestConfigPath); got != before {
+		t.Errorf("expected no request to sailor while open, got %d", got-before)
+	}
+	if config, _ := consumer.Get(); config["from"] != "fallback" {
+		t.Errorf("expected the fallback config, got %v", config)
+	}
+
+	_, err := consumer.pullResource(context.Background(), &consumer.opts.Resources[0])
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestCircuitHalfOpenRecovers(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"from": "sailor"})
+	server.SetFallback("test", opts.CONFIGS, []byte(`{"from": "fallback"}`))
+	server.SetStatus(503)
+
+	registry := metrics.NewRegistry()
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:      []opts.ResourceOption{configPullOnce()},
+		Connection:     fallbackConn(server),
+		Metrics:        registry,
+		CircuitBreaker: &opts.CircuitBreakerOption{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	consumer.Refresh(context.Background(), opts.CONFIGS, "")
+
+	server.SetStatus(0)
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	if config, _ := consumer.Get(); config["from"] != "sailor" {
+		t.Errorf("expected sailor to serve once the probe succeeded, got %v", config)
+	}
+	if st := consumer.Status().Circuit; st.State != CircuitClosed {
+		t.Errorf("expected the circuit to close, got %s", st.State)
+	}
+	if snap := registry.Circuit(); snap.State != "closed" {
+		t.Errorf("expected metrics to report the closed circuit, got %+v", snap)
+	}
+}
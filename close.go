@@ -0,0 +1,60 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"time"
+)
+
+// Close stops keeping the resources up to date: the file watcher is closed and
+// every background pull, retry, stream and report stops. The values already
+// loaded can still be read. Close may be called more than once.
+func (c *Consumer[C, S]) Close() error {
+	if c.stop == nil {
+		return nil
+	}
+	c.stop()
+
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.watcher == nil {
+		return nil
+	}
+	err := c.watcher.Close()
+	c.watcher = nil
+	return err
+}
+
+// closed reports whether Close was called
+func (c *Consumer[C, S]) closed() bool {
+	return c.ctx != nil && c.ctx.Err() != nil
+}
+
+// sleep waits for d and reports false when the consumer was closed meanwhile
+func (c *Consumer[C, S]) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return !c.closed()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
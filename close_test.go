@@ -0,0 +1,72 @@
+package sailor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+const closeTestConfigPath = "/api/v1/resource/test/test/config"
+
+func TestCloseStopsPulling(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{{
+			Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 10 * time.Millisecond},
+		}},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	sailortest.Eventually(t, time.Second, func() bool { return server.Requests(closeTestConfigPath) >= 3 })
+
+	if err := consumer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.Close(); err != nil {
+		t.Errorf("expected closing twice to be fine, got %v", err)
+	}
+
+	// a pull already on its way may still land
+	time.Sleep(20 * time.Millisecond)
+	pulls := server.Requests(closeTestConfigPath)
+	time.Sleep(100 * time.Millisecond)
+	if got := server.Requests(closeTestConfigPath); got != pulls {
+		t.Errorf("expected no pull after Close, got %d more", got-pulls)
+	}
+	assertRelease(t, consumer, "1")
+}
+
+func TestCloseStopsRetrying(t *testing.T) {
+	server := sailortest.NewServer(t)
+
+	optional := false
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{{
+			Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 20 * time.Millisecond},
+			Required: &optional,
+		}},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the retries never wait longer than the PullInterval
+	sailortest.Eventually(t, time.Second, func() bool { return server.Requests(closeTestConfigPath) >= 10 })
+
+	consumer.Close()
+	time.Sleep(30 * time.Millisecond)
+	retries := server.Requests(closeTestConfigPath)
+	time.Sleep(100 * time.Millisecond)
+	if got := server.Requests(closeTestConfigPath); got != retries {
+		t.Errorf("expected no retry after Close, got %d more", got-retries)
+	}
+}
@@ -0,0 +1,163 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+const (
+	// maxReloadEvents is how many reload events are kept for the debug handler
+	maxReloadEvents = 50
+
+	redactedValue = "[REDACTED]"
+)
+
+// ReloadEvent is a single attempt at loading a resource
+type ReloadEvent struct {
+	At      time.Time         `json:"at"`
+	Kind    opts.ResourceKind `json:"kind"`
+	Name    string            `json:"name,omitempty"`
+	Source  Source            `json:"source"`
+	Version string            `json:"version,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type debugConnection struct {
+	Target    string `json:"target"`
+	Namespace string `json:"namespace"`
+	App       string `json:"app"`
+	Env       string `json:"env,omitempty"`
+}
+
+type debugMisc struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type debugState struct {
+	Connection debugConnection   `json:"connection"`
+	Config     any               `json:"config"`
+	Secrets    map[string]string `json:"secrets"`
+	Misc       []debugMisc       `json:"misc"`
+	Status     Status            `json:"status"`
+	Events     []ReloadEvent     `json:"events"`
+}
+
+// recordEvent keeps the event in the bounded reload history, the caller must
+// hold statesMu
+func (c *Consumer[C, S]) recordEvent(ev ReloadEvent) {
+	c.events = append(c.events, ev)
+	if len(c.events) > maxReloadEvents {
+		c.events = slices.Delete(c.events, 0, len(c.events)-maxReloadEvents)
+	}
+}
+
+// DebugHandler serves the live state of the consumer as JSON: the effective
+// config, secret key names with their values redacted, misc resources with
+// their size and hash, the connection target without credentials, the status
+// of every resource and the most recent reload events.
+//
+// It is meant for on-call introspection and should not be exposed publicly.
+func (c *Consumer[C, S]) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		state := debugState{
+			Secrets: map[string]string{},
+			Misc:    []debugMisc{},
+			Status:  c.Status(),
+		}
+
+		if conn := c.opts.Connection; conn != nil {
+			state.Connection = debugConnection{
+				Target:    redactConnectionTarget(conn),
+				Namespace: conn.Namespace,
+				App:       conn.App,
+				Env:       conn.Env,
+			}
+		}
+
+		// the values are read as they are, the getters may wait for a first load
+		if config := c.configs.Load(); config != nil {
+			state.Config = *config
+		}
+
+		if secrets := c.secrets.Load(); secrets != nil {
+			state.Secrets = redactSecrets(*secrets)
+		}
+
+		if miscPtr := c.misc.Load(); miscPtr != nil {
+			for name, b := range *miscPtr {
+				sum := sha256.Sum256(b)
+				state.Misc = append(state.Misc, debugMisc{Name: name, Size: len(b), SHA256: hex.EncodeToString(sum[:])})
+			}
+			sort.Slice(state.Misc, func(i, j int) bool { return state.Misc[i].Name < state.Misc[j].Name })
+		}
+
+		c.statesMu.Lock()
+		state.Events = slices.Clone(c.events)
+		c.statesMu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(state)
+	})
+}
+
+// redactConnectionTarget returns where the consumer connects to without any
+// credentials that may be part of ConnectionOption.URI
+func redactConnectionTarget(conn *opts.ConnectionOption) string {
+	if conn.URI == "" {
+		return conn.Addr
+	}
+
+	if _, err := url.Parse(conn.URI); err != nil {
+		return conn.Addr
+	}
+
+	return redactURL(conn.URI)
+}
+
+// redactSecrets keeps only the key names of the secrets
+func redactSecrets[S any](secrets S) map[string]string {
+	redacted := map[string]string{}
+
+	b, err := json.Marshal(secrets)
+	if err != nil {
+		return redacted
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(b, &values); err != nil {
+		return redacted
+	}
+
+	for k := range values {
+		redacted[k] = redactedValue
+	}
+
+	return redacted
+}
@@ -1,74 +1,49 @@
 package sailor
 
 import (
-	"fmt"
-	"os"
+	"log/slog"
+	"time"
 
 	"github.com/sailorhq/sailor-go/pkg/opts"
 )
 
-const (
-	ansiReset   = "\033[0m"
-	ansiBold    = "\033[1m"
-	ansiDim     = "\033[2m"
-	ansiRed     = "\033[31m"
-	ansiGreen   = "\033[32m"
-	ansiYellow  = "\033[33m"
-	ansiBlue    = "\033[34m"
-	ansiMagenta = "\033[35m"
-	ansiCyan    = "\033[36m"
-)
-
-var devPrefix = ansiBold + ansiCyan + "[dev]" + ansiReset
-
-// devLogLine prints a single structured DEV log line to stderr.
-//
-//	[dev]  <kind>    <icon> <label>   <detail>
-func devLogLine(kind opts.ResourceKind, icon, label, labelColor, detail string) {
-	var kindPart string
-	if kind != "" {
-		kindPart = fmt.Sprintf("%s%-8s%s", ansiYellow, string(kind), ansiReset)
-	} else {
-		kindPart = "        " // 8 spaces — aligns with padded kind column
-	}
-
-	statusPart := fmt.Sprintf("%s%s %-10s%s", labelColor, icon, label, ansiReset)
-	detailPart := fmt.Sprintf("%s%s%s", ansiDim, detail, ansiReset)
-
-	fmt.Fprintf(os.Stderr, "  %s  %s  %s  %s\n",
-		devPrefix, kindPart, statusPart, detailPart)
-}
-
-func devLogBanner(env, host, ns, app string) {
-	line := ansiCyan + "─────────────────────────────────────────────────────────────────────" + ansiReset
-	fmt.Fprintf(os.Stderr, "\n  %s┌── sailor %s\n", ansiCyan, line+ansiReset)
-	fmt.Fprintf(os.Stderr, "  %s│%s  env   %s%s%s  →  %s%s%s\n",
-		ansiCyan, ansiReset, ansiBold, env, ansiReset, ansiDim, host, ansiReset)
-	fmt.Fprintf(os.Stderr, "  %s│%s  app   %s%s / %s%s\n",
-		ansiCyan, ansiReset, ansiBold, ns, app, ansiReset)
-	fmt.Fprintf(os.Stderr, "  %s└%s\n\n", ansiCyan, line+ansiReset)
-}
-
-func devLogCacheHit(kind opts.ResourceKind, path string) {
-	devLogLine(kind, "●", "cache hit", ansiBold+ansiGreen, path)
-}
+// DEV mode events go through the consumer logger like every other event, so
+// turning InitOption.Logging off silences them as well
 
-func devLogFetching(kind opts.ResourceKind, url string) {
-	devLogLine(kind, "↓", "fetching", ansiBold+ansiBlue, url)
+// devLogConnected announces the ~/.sailor/config env the consumer talks to
+func (c *Consumer[C, S]) devLogConnected(conn *opts.ConnectionOption) {
+	c.log().Info("sailor dev connected",
+		slog.String("env", conn.Env),
+		slog.String("addr", conn.Addr),
+		slog.String("namespace", conn.Namespace),
+		slog.String("app", conn.App),
+	)
 }
 
-func devLogCached(kind opts.ResourceKind, path string) {
-	devLogLine(kind, "✓", "cached", ansiGreen, path)
+func (c *Consumer[C, S]) devLogCacheHit(res *opts.ResourceOption, path string, data []byte, started time.Time) {
+	attrs := resourceAttrs(res.Def.Kind, res.Def.Name, SourceDev, resourceVersion(nil, data), started)
+	c.log().Debug("sailor dev cache hit", append(attrs, slog.String("path", path))...)
 }
 
-func devLogWatching(kind opts.ResourceKind, dir string) {
-	devLogLine(kind, "→", "watching", ansiBold+ansiMagenta, dir)
+func (c *Consumer[C, S]) devLogFetching(res *opts.ResourceOption, url string) {
+	c.log().Debug("sailor dev fetching",
+		slog.String("kind", string(res.Def.Kind)),
+		slog.String("name", res.Def.Name),
+		slog.String("source", string(SourceDev)),
+		slog.String("url", redactURL(url)),
+	)
 }
 
-func devLogReloaded(kind opts.ResourceKind, path string) {
-	devLogLine(kind, "↺", "reloaded", ansiBold+ansiCyan, path)
+func (c *Consumer[C, S]) devLogCached(res *opts.ResourceOption, path string, data []byte, started time.Time) {
+	attrs := resourceAttrs(res.Def.Kind, res.Def.Name, SourceDev, resourceVersion(nil, data), started)
+	c.log().Debug("sailor dev resource cached", append(attrs, slog.String("path", path))...)
 }
 
-func devLogReloadError(kind opts.ResourceKind, detail string) {
-	devLogLine(kind, "✕", "reload err", ansiBold+ansiRed, detail)
+func (c *Consumer[C, S]) devLogWatching(res *opts.ResourceOption, dir string) {
+	c.log().Debug("sailor dev watching cache",
+		slog.String("kind", string(res.Def.Kind)),
+		slog.String("name", res.Def.Name),
+		slog.String("source", string(SourceDev)),
+		slog.String("dir", dir),
+	)
 }
@@ -0,0 +1,302 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+const (
+	// endpointEjectAfter consecutive failures take an endpoint out of rotation
+	endpointEjectAfter = 3
+
+	// an ejected endpoint is back in rotation after endpointEjectMinTime,
+	// doubling every time it is ejected again up to endpointEjectMaxTime
+	endpointEjectMinTime = 10 * time.Second
+	endpointEjectMaxTime = 5 * time.Minute
+)
+
+// endpoint is a single Sailor replica and what is known about its health
+type endpoint struct {
+	addr string
+	url  *url.URL
+
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+	lastError    string
+}
+
+// endpointPool spreads the requests of a consumer across the Sailor replicas
+// of its connection. Requests go to the last endpoint which answered, an
+// endpoint failing endpointEjectAfter times in a row is only tried once the
+// healthy ones failed too, until its ejection is over.
+type endpointPool struct {
+	mu sync.Mutex
+
+	// primary is the Addr the request URLs are built with
+	primary   *url.URL
+	endpoints []*endpoint
+	preferred int
+}
+
+// EndpointStatus is the health of a single Sailor endpoint
+type EndpointStatus struct {
+	Addr                string    `json:"addr"`
+	Preferred           bool      `json:"preferred"`
+	Ejected             bool      `json:"ejected"`
+	EjectedUntil        time.Time `json:"ejected_until,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// newEndpointPool returns nil when the connection has a single endpoint. With
+// replicas every address must be a URL, a bare host cannot be failed over to.
+func newEndpointPool(conn *opts.ConnectionOption) (*endpointPool, error) {
+	if conn == nil || len(conn.Addrs) == 0 {
+		return nil, nil
+	}
+
+	var pool endpointPool
+	seen := map[string]bool{}
+	for _, addr := range append([]string{conn.Addr}, conn.Addrs...) {
+		u, err := url.Parse(addr)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w: got %q", ErrInvalidReplicaAddr, redactURL(addr))
+		}
+		if pool.primary == nil {
+			pool.primary = u
+		}
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		pool.endpoints = append(pool.endpoints, &endpoint{addr: addr, url: u})
+	}
+
+	if len(pool.endpoints) < 2 {
+		return nil, nil
+	}
+	return &pool, nil
+}
+
+// order is the order to try the endpoints in: the preferred one and the other
+// endpoints in rotation, then the ejected ones soonest back first
+func (p *endpointPool) order(now time.Time) []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var healthy, ejected []*endpoint
+	for i := range p.endpoints {
+		ep := p.endpoints[(p.preferred+i)%len(p.endpoints)]
+		if now.Before(ep.ejectedUntil) {
+			ejected = append(ejected, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+
+	for i := 1; i < len(ejected); i++ {
+		for j := i; j > 0 && ejected[j].ejectedUntil.Before(ejected[j-1].ejectedUntil); j-- {
+			ejected[j], ejected[j-1] = ejected[j-1], ejected[j]
+		}
+	}
+
+	return append(healthy, ejected...)
+}
+
+// succeeded makes the endpoint the preferred one and forgets its failures
+func (p *endpointPool) succeeded(ep *endpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ep.failures = 0
+	ep.ejections = 0
+	ep.ejectedUntil = time.Time{}
+	ep.lastError = ""
+	for i, e := range p.endpoints {
+		if e == ep {
+			p.preferred = i
+		}
+	}
+}
+
+// failed counts a failure of the endpoint and ejects it when it failed
+// endpointEjectAfter times in a row
+func (p *endpointPool) failed(ep *endpoint, cause string, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ep.lastError = cause
+	ep.failures++
+	if ep.failures < endpointEjectAfter {
+		return
+	}
+
+	ejectFor := endpointEjectMinTime << ep.ejections
+	if ejectFor <= 0 || ejectFor > endpointEjectMaxTime {
+		ejectFor = endpointEjectMaxTime
+	}
+	ep.ejections++
+	ep.failures = 0
+	ep.ejectedUntil = now.Add(ejectFor)
+}
+
+// status reports the health of every endpoint
+func (p *endpointPool) status(now time.Time) []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]EndpointStatus, 0, len(p.endpoints))
+	for i, ep := range p.endpoints {
+		st := EndpointStatus{
+			Addr:                redactURL(ep.addr),
+			Preferred:           i == p.preferred,
+			ConsecutiveFailures: ep.failures,
+			LastError:           ep.lastError,
+		}
+		if now.Before(ep.ejectedUntil) {
+			st.Ejected = true
+			st.EjectedUntil = ep.ejectedUntil
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// endpointFor is the endpoint a response came from, empty when the consumer
+// has a single endpoint
+func (p *endpointPool) endpointFor(u *url.URL) string {
+	if p == nil || u == nil {
+		return ""
+	}
+	for _, ep := range p.endpoints {
+		if ep.url.Scheme == u.Scheme && ep.url.Host == u.Host &&
+			strings.HasPrefix(u.Path, ep.url.Path) {
+			return redactURL(ep.addr)
+		}
+	}
+	return ""
+}
+
+// rewrite points a request built against the primary address at ep, requests
+// to anything else (fallback, a custom URL) are not touched
+func (p *endpointPool) rewrite(req *http.Request, ep *endpoint) (*http.Request, bool) {
+	u := req.URL
+	if u.Scheme != p.primary.Scheme || u.Host != p.primary.Host ||
+		!strings.HasPrefix(u.Path, p.primary.Path) {
+		return req, false
+	}
+	if ep.url == p.primary {
+		return req, true
+	}
+
+	target := *u
+	target.Scheme = ep.url.Scheme
+	target.Host = ep.url.Host
+	target.Path = strings.TrimSuffix(ep.url.Path, "/") + strings.TrimPrefix(u.Path, strings.TrimSuffix(p.primary.Path, "/"))
+	target.RawPath = ""
+
+	out := req.Clone(req.Context())
+	out.URL = &target
+	out.Host = ""
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return req, false
+		}
+		out.Body = body
+	}
+	return out, true
+}
+
+// isEndpointFailure tells if the replica failed to serve the request, as
+// opposed to the request itself being rejected
+func isEndpointFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// roundTrip sends the request to the preferred endpoint, moving on to the next
+// one when an endpoint cannot be reached or answers with a server error. The
+// last answer is returned when every endpoint failed.
+func (c *Consumer[C, S]) roundTrip(req *http.Request) (*http.Response, error) {
+	pool := c.endpoints
+	if pool == nil || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return c.send(req)
+	}
+
+	endpoints := pool.order(time.Now())
+	for i, ep := range endpoints {
+		attempt, ok := pool.rewrite(req, ep)
+		if !ok {
+			return c.send(req)
+		}
+
+		resp, err := c.send(attempt)
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		if !isEndpointFailure(resp, err) {
+			pool.succeeded(ep)
+			return resp, err
+		}
+
+		cause := ""
+		if err != nil {
+			cause = err.Error()
+		} else {
+			cause = c.statusError(resp.StatusCode).Error()
+		}
+		pool.failed(ep, cause, time.Now())
+
+		if i == len(endpoints)-1 {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+
+	return nil, errors.New("sailor has no endpoint to send the request to")
+}
+
+// servedBy reports the endpoint which served the resource when the consumer
+// fails over across several of them
+func (c *Consumer[C, S]) servedBy(kind opts.ResourceKind, name, endpoint string) {
+	if endpoint == "" {
+		return
+	}
+	if em, ok := c.metrics.(metrics.EndpointMetrics); ok {
+		em.ServedBy(string(kind), name, endpoint)
+	}
+}
@@ -0,0 +1,198 @@
+package sailor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+const endpointsTestConfigPath = "/api/v1/resource/test/test/config"
+
+// replicatedConn connects to the primary with the replica as a failover
+func replicatedConn(primary, replica *sailortest.Server) *opts.ConnectionOption {
+	conn := primary.Connection("test", "test", "ak", "sk")
+	conn.Addrs = []string{replica.URL}
+	return conn
+}
+
+func TestEndpointFailover(t *testing.T) {
+	primary := sailortest.NewServer(t)
+	replica := sailortest.NewServer(t)
+	primary.SetStatus(503)
+	replica.SetConfig("test", "test", map[string]string{"served": "replica"})
+
+	registry := metrics.NewRegistry()
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: replicatedConn(primary, replica),
+		Metrics:    registry,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if config, _ := consumer.Get(); config["served"] != "replica" {
+		t.Errorf("expected the replica to serve the config, got %v", config)
+	}
+
+	snapshots := registry.Snapshot()
+	if len(snapshots) != 1 || snapshots[0].Endpoint != replica.URL {
+		t.Errorf("expected metrics to report %s, got %+v", replica.URL, snapshots)
+	}
+
+	endpoints := consumer.Status().Endpoints
+	if len(endpoints) != 2 || endpoints[0].Preferred || !endpoints[1].Preferred {
+		t.Errorf("expected the replica to be preferred, got %+v", endpoints)
+	}
+	if endpoints[0].ConsecutiveFailures != 1 || endpoints[0].LastError == "" {
+		t.Errorf("expected the primary failure to be tracked, got %+v", endpoints[0])
+	}
+}
+
+func TestEndpointPrefersLastHealthy(t *testing.T) {
+	primary := sailortest.NewServer(t)
+	replica := sailortest.NewServer(t)
+	primary.SetStatus(503)
+	replica.SetConfig("test", "test", map[string]string{"served": "replica"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: replicatedConn(primary, replica),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the primary recovers but the replica keeps serving as long as it is healthy
+	primary.SetStatus(0)
+	primary.SetConfig("test", "test", map[string]string{"served": "primary"})
+	before := primary.Requests(endpointsTestConfigPath)
+
+	for range 3 {
+		if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := primary.Requests(endpointsTestConfigPath); got != before {
+		t.Errorf("expected no more requests to the primary, got %d", got-before)
+	}
+	if config, _ := consumer.Get(); config["served"] != "replica" {
+		t.Errorf("expected the replica to serve the config, got %v", config)
+	}
+}
+
+func TestEndpointNotFoundDoesNotFailOver(t *testing.T) {
+	primary := sailortest.NewServer(t)
+	replica := sailortest.NewServer(t)
+	replica.SetConfig("test", "test", map[string]string{"served": "replica"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: replicatedConn(primary, replica),
+	})
+	if err := consumer.Start(); err == nil {
+		t.Fatal("expected the 404 of the primary to be returned")
+	}
+
+	if got := replica.Requests(endpointsTestConfigPath); got != 0 {
+		t.Errorf("expected the replica not to be asked, got %d requests", got)
+	}
+}
+
+func TestEndpointPoolEjection(t *testing.T) {
+	pool, err := newEndpointPool(&opts.ConnectionOption{
+		Addr:  "https://a.example",
+		Addrs: []string{"https://b.example", "https://c.example"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := pool.endpoints[0], pool.endpoints[1], pool.endpoints[2]
+	now := time.Now()
+
+	for range endpointEjectAfter {
+		pool.failed(a, "down", now)
+	}
+	if order := pool.order(now); order[0] != b || order[1] != c || order[2] != a {
+		t.Errorf("expected the ejected endpoint to be tried last")
+	}
+
+	// the healthy endpoint which answered is preferred from now on
+	pool.succeeded(c)
+	if order := pool.order(now); order[0] != c || order[1] != b || order[2] != a {
+		t.Errorf("expected the last healthy endpoint to be tried first")
+	}
+
+	// back in rotation once the ejection is over, ejected for longer next time
+	later := now.Add(endpointEjectMinTime)
+	if order := pool.order(later); order[2] == a {
+		t.Errorf("expected the endpoint to be back in rotation")
+	}
+	for range endpointEjectAfter {
+		pool.failed(a, "down", later)
+	}
+	if want := later.Add(2 * endpointEjectMinTime); !a.ejectedUntil.Equal(want) {
+		t.Errorf("expected ejection until %v, got %v", want, a.ejectedUntil)
+	}
+
+	pool.succeeded(a)
+	if a.failures != 0 || a.ejections != 0 || !a.ejectedUntil.IsZero() {
+		t.Errorf("expected success to reset the endpoint, got %+v", a)
+	}
+}
+
+func TestParseURIMultipleHosts(t *testing.T) {
+	uri := "sailor+https://ak:sk@a.example:8443,b.example:8443,c.example/ns/app?timeout=5s"
+	conn, err := parseURI(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.URI != uri {
+		t.Errorf("expected the URI to be kept as given, got %s", conn.URI)
+	}
+
+	if conn.Addr != "https://a.example:8443" {
+		t.Errorf("expected the first host as Addr, got %s", conn.Addr)
+	}
+	if len(conn.Addrs) != 2 || conn.Addrs[0] != "https://b.example:8443" || conn.Addrs[1] != "https://c.example" {
+		t.Errorf("unexpected Addrs %v", conn.Addrs)
+	}
+	if conn.SocketTimeout != 5*time.Second {
+		t.Errorf("expected query options to still apply, got %v", conn.SocketTimeout)
+	}
+
+	again, err := parseURI(conn.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Addr != conn.Addr || len(again.Addrs) != 2 || again.Addrs[1] != conn.Addrs[1] {
+		t.Errorf("String() does not round trip the hosts: %s", conn.String())
+	}
+}
+
+func TestEndpointPoolPlainSailorURI(t *testing.T) {
+	conn, err := parseURI("sailor://ak:sk@a.example:7766,b.example:7766/ns/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := newEndpointPool(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool == nil || len(pool.endpoints) != 2 || pool.endpoints[1].url.Host != "b.example:7766" {
+		t.Fatalf("expected both hosts to be failed over to, got %+v", pool)
+	}
+
+	// a bare host would silently disable failover
+	if _, err := newEndpointPool(&opts.ConnectionOption{Addr: "a.example:7766", Addrs: []string{"b.example:7766"}}); !errors.Is(err, ErrInvalidReplicaAddr) {
+		t.Errorf("expected ErrInvalidReplicaAddr, got %v", err)
+	}
+}
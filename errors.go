@@ -15,7 +15,15 @@
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package sailor
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sailorhq/sailor-go/pkg/envelope"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
 
 var (
 	ErrNewConsumerEmptyResourceList = errors.New("no resources to manage, pass Resources inside opts")
@@ -28,8 +36,174 @@ var (
 	ErrMiscNotLoaded                = errors.New("misc resource are not loaded")
 	ErrInvalidURIPrefix             = errors.New("invalid sailor URI prefix")
 	ErrMissingURIPathComponents     = errors.New("either ns or app missing from URI")
+	ErrInvalidURIOption             = errors.New("invalid sailor URI query option")
+	ErrInvalidCAFile                = errors.New("cannot read certificate authorities from CAFile")
+	ErrInvalidReplicaAddr           = errors.New("with Connection.Addrs set every address must be a URL such as https://sailor.example")
 	ErrLocalConfigNotFound          = errors.New("~/.sailor/config not found; run 'sailor login' first")
 	ErrLocalConfigInvalid           = errors.New("~/.sailor/config is malformed")
-	ErrLocalConfigEnvNotFound       = errors.New("active env not found in ~/.sailor/config manifest")
+	ErrLocalConfigEnvNotFound       = errors.New("env not found in ~/.sailor/config manifest")
 	ErrLocalConfigMissingNsOrApp    = errors.New("Namespace and App must be set in Connection when UseSailorConfig is true")
+	ErrSecretsNoCredentials         = errors.New("cannot decrypt vault secrets without AccessKey and SecretKey, set Connection or use PLAINTEXT/BASE64 SecretEncoding")
+	ErrSecretValueNotBase64         = errors.New("secret value is not valid base64")
+	ErrUnknownSecretEncoding        = errors.New("unknown SecretEncoding on secret resource")
+	ErrResourceNotManaged           = errors.New("resource is not managed by this consumer, add it to Resources")
+	ErrCircuitOpen                  = errors.New("sailor circuit breaker is open, not calling sailor until it recovers")
+	ErrVersionNotInHistory          = errors.New("version is not in the history of the resource")
+	ErrVersionUnavailable           = errors.New("sailor cannot serve the pinned version of the resource")
+	ErrVersionMismatch              = errors.New("sailor served another version than the pinned one")
+	ErrTokenExpired                 = errors.New("sailor token from ~/.sailor/config is expired or revoked, run 'sailor login' and it is picked up on the next fetch")
+	ErrWebhookBadSignature          = errors.New("webhook signature does not match")
+	ErrWebhookStale                 = errors.New("webhook timestamp is missing or outside the allowed window")
+	ErrWebhookReplayed              = errors.New("webhook nonce was already used")
+	ErrPayloadUnsigned              = errors.New("payload is not signed, InitOption.Signature requires a signature")
+	ErrPayloadBadSignature          = errors.New("payload signature does not match any trusted key")
+	ErrInvalidSignatureKey          = errors.New("Signature.PublicKeys must hold ed25519 public keys")
+	ErrPayloadNoCredentials         = errors.New("cannot encrypt fallback and cache payloads without AccessKey and SecretKey, set Connection or turn EncryptedFallback off")
+	ErrPayloadNotEncrypted          = envelope.ErrNotSealed
+)
+
+// Stage is the step of loading a resource which failed
+type Stage string
+
+const (
+	// StageFetch is reading the file or talking to the server
+	StageFetch Stage = "fetch"
+	// StageStatus is the server answering with a status other than 200
+	StageStatus Stage = "status"
+	// StageDecode is turning the payload into the resource type
+	StageDecode Stage = "decode"
+	// StageDecrypt is decrypting vault secrets
+	StageDecrypt Stage = "decrypt"
+	// StageVersion is the server serving another version than the one pinned
+	// in ResourceDefinition.Version
+	StageVersion Stage = "version"
+	// StageSignature is the payload not being signed by a key trusted in
+	// InitOption.Signature
+	StageSignature Stage = "signature"
 )
+
+// ResourceError is returned when a resource cannot be loaded, use errors.As to
+// find out which resource failed, from where and at which stage
+type ResourceError struct {
+	Kind   opts.ResourceKind
+	Name   string
+	Source Source
+	Stage  Stage
+
+	// URL is where the resource was fetched from with credentials redacted, or
+	// the file path for volume resources
+	URL string
+
+	// StatusCode is the HTTP status the server answered with, if any
+	StatusCode int
+
+	// Err is the cause of the failure
+	Err error
+
+	// Fallback is why fallback could not serve the resource either, nil when
+	// fallback was not attempted
+	Fallback error
+}
+
+func (e *ResourceError) Error() string {
+	var b strings.Builder
+	b.WriteString(resourceKey{e.Kind, e.Name}.String())
+	b.WriteString(": ")
+	b.WriteString(e.describe())
+	if e.Fallback != nil {
+		b.WriteString("; fallback ")
+		var fe *ResourceError
+		if errors.As(e.Fallback, &fe) {
+			b.WriteString(fe.describe())
+		} else {
+			b.WriteString(e.Fallback.Error())
+		}
+	}
+	return b.String()
+}
+
+// describe is the error without the resource it is about
+func (e *ResourceError) describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s failed", e.Stage)
+	if e.Source != "" {
+		fmt.Fprintf(&b, " from %s", e.Source)
+	}
+	if e.URL != "" {
+		fmt.Fprintf(&b, " (%s)", e.URL)
+	}
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " with status %d", e.StatusCode)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %s", e.Err)
+	}
+	return b.String()
+}
+
+func (e *ResourceError) Unwrap() []error {
+	errs := []error{e.Err}
+	if e.Fallback != nil {
+		errs = append(errs, e.Fallback)
+	}
+	return errs
+}
+
+// decryptError marks a failure to decrypt vault secrets
+type decryptError struct {
+	err error
+}
+
+func (e *decryptError) Error() string { return e.err.Error() }
+func (e *decryptError) Unwrap() error { return e.err }
+
+// resourceErr gives err the context of the resource it is about unless it is
+// a ResourceError already
+func resourceErr(kind opts.ResourceKind, name string, err error) error {
+	var re *ResourceError
+	if errors.As(err, &re) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", resourceKey{kind, name}, err)
+}
+
+// withFallback attaches the fallback failure to the primary one
+func withFallback(primary, fallback error) error {
+	var re *ResourceError
+	if !errors.As(primary, &re) {
+		return errors.Join(primary, fallback)
+	}
+
+	withFallback := *re
+	withFallback.Fallback = fallback
+	return &withFallback
+}
+
+// sensitiveQueryKeys are query parameters whose value is redacted from URLs
+var sensitiveQueryKeys = []string{"token", "key", "secret", "sig", "password"}
+
+// redactURL removes credentials from a URL: user info and the value of query
+// parameters which look sensitive. Anything which does not parse as a URL is
+// returned as is, like file paths.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		for k := range query {
+			for _, sensitive := range sensitiveQueryKeys {
+				if strings.Contains(strings.ToLower(k), sensitive) {
+					query.Set(k, redactedValue)
+					break
+				}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
@@ -0,0 +1,116 @@
+package sailor
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func startForError(t *testing.T, server *sailortest.Server, resource opts.ResourceOption) *ResourceError {
+	t.Helper()
+
+	consumer, err := NewConsumer[map[string]string, map[string]string](opts.InitOption{
+		Resources:  []opts.ResourceOption{resource},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	err = consumer.Start()
+	var re *ResourceError
+	if !errors.As(err, &re) {
+		t.Fatalf("expected a ResourceError got %v", err)
+	}
+	return re
+}
+
+func TestResourceErrorStatus(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetStatus(http.StatusServiceUnavailable)
+
+	re := startForError(t, server, opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+		FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+	})
+
+	if re.Kind != opts.MISC || re.Name != "certs" || re.Source != SourcePull || re.Stage != StageStatus || re.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("unexpected error %+v", re)
+	}
+	if re.URL != server.URL+"/api/v1/resource/test/test/misc/certs" {
+		t.Errorf("unexpected url %s", re.URL)
+	}
+
+	var fallback *ResourceError
+	if !errors.As(re.Fallback, &fallback) || fallback.Source != SourceFallback {
+		t.Errorf("expected the fallback failure to be attached got %v", re.Fallback)
+	}
+	if !errors.Is(re, ErrFetchFallbackFailed) {
+		t.Error("expected the fallback failure to be reachable with errors.Is")
+	}
+	if !strings.HasPrefix(re.Error(), "misc certs: status failed from pull") {
+		t.Errorf("unexpected message %q", re.Error())
+	}
+}
+
+func TestResourceErrorDecode(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetRaw("test", "test", opts.CONFIGS, "", []byte("not json"))
+
+	re := startForError(t, server, opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+		FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+	})
+
+	if re.Kind != opts.CONFIGS || re.Source != SourcePull || re.Stage != StageDecode || re.StatusCode != http.StatusOK || re.Fallback != nil {
+		t.Errorf("unexpected error %+v", re)
+	}
+}
+
+func TestResourceErrorDecrypt(t *testing.T) {
+	server := sailortest.NewServer(t)
+	if err := server.SetSecrets("test", "test", "ak", "other", map[string]string{"password": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	re := startForError(t, server, opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.SECRETS},
+		FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+	})
+
+	if re.Kind != opts.SECRETS || re.Stage != StageDecrypt {
+		t.Errorf("unexpected error %+v", re)
+	}
+}
+
+func TestResourceErrorVolume(t *testing.T) {
+	server := sailortest.NewServer(t)
+
+	re := startForError(t, server, opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.CONFIGS, Path: testFolder},
+		FetchDef: opts.FetchDefinition{Fetch: opts.VOLUME},
+	})
+
+	if re.Source != SourceVolume || re.Stage != StageFetch || re.URL != testFolder+"/_config" {
+		t.Errorf("unexpected error %+v", re)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := map[string]string{
+		"https://ak:sk@sailor.example.com/api/v1":           "https://sailor.example.com/api/v1",
+		"https://sailor.example.com/api?token=abc&env=prod": "https://sailor.example.com/api?env=prod&token=%5BREDACTED%5D",
+		"./_tests/_config": "./_tests/_config",
+	}
+
+	for in, want := range tests {
+		if got := redactURL(in); got != want {
+			t.Errorf("redactURL(%q) = %q want %q", in, got, want)
+		}
+	}
+}
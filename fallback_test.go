@@ -58,6 +58,7 @@ func TestFallbackConfig(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -133,6 +134,7 @@ func TestFallbackSecrets(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -195,6 +197,7 @@ func TestFallbackMisc(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -264,6 +267,7 @@ func TestPullFallbackConfig(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -343,6 +347,7 @@ func TestPullFallbackSecrets(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -409,6 +414,7 @@ func TestPullFallbackMisc(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -459,6 +465,7 @@ func TestPullFallbackError(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	// mock fallback not set or unreachable
 	err = consumer.Start()
@@ -507,6 +514,7 @@ func TestFetchFallbackServerError(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	err = consumer.Start()
 	// Just executing for coverage
@@ -0,0 +1,228 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// resourceKey identifies a resource managed by the consumer
+type resourceKey struct {
+	kind opts.ResourceKind
+	name string
+}
+
+// String names the resource in errors, as "config" or "misc certs"
+func (k resourceKey) String() string {
+	if k.name == "" {
+		return string(k.kind)
+	}
+	return string(k.kind) + " " + k.name
+}
+
+// resourceState is what the consumer knows about a resource at a point in time
+type resourceState struct {
+	loaded      bool
+	source      Source
+	version     string
+	lastSuccess time.Time
+	appliedAt   time.Time
+	lastError   error
+	lastErrorAt time.Time
+}
+
+// Status is a snapshot of every resource managed by the consumer
+type Status struct {
+	// Ready is true when every required resource is loaded and none is older
+	// than its ResourceOption.MaxStaleness
+	Ready     bool             `json:"ready"`
+	Resources []ResourceStatus `json:"resources"`
+
+	// Endpoints is the health of every Sailor replica when the connection has
+	// more than one
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+
+	// Circuit is the state of the circuit breaker, nil when it is disabled
+	Circuit *CircuitStatus `json:"circuit,omitempty"`
+}
+
+// ResourceStatus is the state of a single resource
+type ResourceStatus struct {
+	Kind        opts.ResourceKind `json:"kind"`
+	Name        string            `json:"name,omitempty"`
+	Loaded      bool              `json:"loaded"`
+	Source      Source            `json:"source,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	LastSuccess time.Time         `json:"last_success,omitzero"`
+	AppliedAt   time.Time         `json:"applied_at,omitzero"`
+	LastError   string            `json:"last_error,omitempty"`
+	LastErrorAt time.Time         `json:"last_error_at,omitzero"`
+
+	// Staleness is the time since the resource was last successfully stored
+	Staleness time.Duration `json:"staleness"`
+
+	// Ready is false when the resource is not loaded or is older than its
+	// ResourceOption.MaxStaleness, Reason tells which one
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+
+	// Pinned is true while the value in use was put back by Rollback
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// recordSuccess marks the resource as loaded from the given source and returns
+// the version which was in use before
+func (c *Consumer[C, S]) recordSuccess(raw rawResource, at time.Time) (previousVersion string, wasLoaded bool) {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	if c.states == nil {
+		c.states = map[resourceKey]*resourceState{}
+	}
+	st, ok := c.states[resourceKey{raw.kind, raw.name}]
+	if !ok {
+		st = &resourceState{}
+		c.states[resourceKey{raw.kind, raw.name}] = st
+	}
+
+	previousVersion, wasLoaded = st.version, st.loaded
+	if !wasLoaded || previousVersion != raw.version {
+		st.appliedAt = at
+	}
+	st.loaded = true
+	st.source = raw.source
+	st.version = raw.version
+	st.lastSuccess = at
+
+	c.recordEvent(ReloadEvent{At: at, Kind: raw.kind, Name: raw.name, Source: raw.source, Version: raw.version})
+	return previousVersion, wasLoaded
+}
+
+// recordFetched marks a loaded resource as fresh without changing its value,
+// used when a fetched version is not applied because of a pin
+func (c *Consumer[C, S]) recordFetched(kind opts.ResourceKind, name string, at time.Time) {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	if st, ok := c.states[resourceKey{kind, name}]; ok && st.loaded {
+		st.lastSuccess = at
+	}
+}
+
+// recordFailure keeps the last error seen for the resource
+func (c *Consumer[C, S]) recordFailure(kind opts.ResourceKind, name string, source Source, err error, at time.Time) {
+	defer c.signalStored()
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	if c.states == nil {
+		c.states = map[resourceKey]*resourceState{}
+	}
+	st, ok := c.states[resourceKey{kind, name}]
+	if !ok {
+		st = &resourceState{}
+		c.states[resourceKey{kind, name}] = st
+	}
+
+	st.lastError = err
+	st.lastErrorAt = at
+
+	c.recordEvent(ReloadEvent{At: at, Kind: kind, Name: name, Source: source, Error: err.Error()})
+}
+
+// Status returns the state of every resource defined in InitOption.Resources
+func (c *Consumer[C, S]) Status() Status {
+	pinned := c.pinnedResources()
+
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	now := time.Now()
+	status := Status{Ready: true}
+	for _, res := range c.opts.Resources {
+		rs := ResourceStatus{Kind: res.Def.Kind, Name: res.Def.Name, Pinned: pinned[resourceKey{res.Def.Kind, res.Def.Name}]}
+		if st, ok := c.states[resourceKey{res.Def.Kind, res.Def.Name}]; ok {
+			rs.Loaded = st.loaded
+			rs.Source = st.source
+			rs.Version = st.version
+			rs.LastSuccess = st.lastSuccess
+			rs.AppliedAt = st.appliedAt
+			rs.LastErrorAt = st.lastErrorAt
+			if st.lastError != nil {
+				rs.LastError = st.lastError.Error()
+			}
+			if st.loaded {
+				rs.Staleness = now.Sub(st.lastSuccess)
+			}
+		}
+
+		rs.Ready = true
+		switch {
+		case !rs.Loaded:
+			rs.Ready = false
+			rs.Reason = "not loaded"
+		case res.MaxStaleness > 0 && rs.Staleness > res.MaxStaleness:
+			rs.Ready = false
+			rs.Reason = fmt.Sprintf("older than %s", res.MaxStaleness)
+		}
+
+		// an optional resource is still retried in the background and does not
+		// hold the consumer back
+		if !rs.Ready && res.IsRequired() {
+			status.Ready = false
+		}
+		status.Resources = append(status.Resources, rs)
+	}
+
+	if c.endpoints != nil {
+		status.Endpoints = c.endpoints.status(now)
+	}
+	status.Circuit = c.breaker.status()
+
+	return status
+}
+
+// HealthHandler reports the consumer Status and always responds with 200, the
+// process being able to answer is what liveness is about
+func (c *Consumer[C, S]) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeStatus(w, http.StatusOK, c.Status())
+	})
+}
+
+// ReadinessHandler reports the consumer Status and responds with 503 until
+// every resource is loaded and fresh according to ResourceOption.MaxStaleness
+func (c *Consumer[C, S]) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		status := c.Status()
+		code := http.StatusOK
+		if !status.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeStatus(w, code, status)
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,102 @@
+package sailor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// volumeConfig is a config resource read from the test volume
+func volumeConfig(maxStaleness time.Duration) opts.ResourceOption {
+	return opts.ResourceOption{
+		Def: opts.ResourceDefinition{
+			Kind: opts.CONFIGS,
+			Path: testFolder,
+		},
+		FetchDef: opts.FetchDefinition{
+			Fetch: opts.VOLUME,
+		},
+		MaxStaleness: maxStaleness,
+	}
+}
+
+func serveStatus(t *testing.T, h http.Handler) (int, Status) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, status
+}
+
+func TestReadinessBeforeStart(t *testing.T) {
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{volumeConfig(0)},
+		Connection: &opts.ConnectionOption{Namespace: "test", App: "test"},
+	})
+
+	code, status := serveStatus(t, consumer.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || status.Ready {
+		t.Errorf("expected not ready before start, got %d %+v", code, status)
+	}
+
+	code, _ = serveStatus(t, consumer.HealthHandler())
+	if code != http.StatusOK {
+		t.Errorf("expected health to be 200 got %d", code)
+	}
+}
+
+func TestReadinessLoaded(t *testing.T) {
+	createTestFile(map[string]string{"app": "sailor"}, "_config")
+	defer removeTestFile("_config")
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{volumeConfig(0)},
+		Connection: &opts.ConnectionOption{Namespace: "test", App: "test"},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	code, status := serveStatus(t, consumer.ReadinessHandler())
+	if code != http.StatusOK || !status.Ready {
+		t.Fatalf("expected ready, got %d %+v", code, status)
+	}
+
+	rs := status.Resources[0]
+	if !rs.Loaded || rs.Source != SourceVolume || rs.Version == "" || rs.LastSuccess.IsZero() {
+		t.Errorf("unexpected resource status %+v", rs)
+	}
+}
+
+func TestReadinessStale(t *testing.T) {
+	createTestFile(map[string]string{"app": "sailor"}, "_config")
+	defer removeTestFile("_config")
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{volumeConfig(10 * time.Millisecond)},
+		Connection: &opts.ConnectionOption{Namespace: "test", App: "test"},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	code, status := serveStatus(t, consumer.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || status.Ready {
+		t.Fatalf("expected stale resource to be not ready, got %d %+v", code, status)
+	}
+
+	if status.Resources[0].Reason == "" {
+		t.Error("expected a reason for the stale resource")
+	}
+}
@@ -5,37 +5,11 @@ import (
 	"testing"
 	"time"
 	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
 )
 
 func EncryptSecretForTest(ak, sk string, secrets map[string]string) (map[string]vault.SecretRecord, error) {
-	kek, err := vault.DeriveKEK(sk, []byte(ak))
-	if err != nil {
-		return nil, err
-	}
-
-	encSecrets := make(map[string]vault.SecretRecord)
-	for k, v := range secrets {
-		dek, err := vault.GenerateDEK()
-		if err != nil {
-			return nil, err
-		}
-
-		encSecret, _, err := vault.EncryptWithDEK(v, dek)
-		if err != nil {
-			return nil, err
-		}
-
-		encDek, err := vault.EncryptDEK(dek, kek)
-		if err != nil {
-			return nil, err
-		}
-
-		encSecrets[k] = vault.SecretRecord{
-			EncryptedDEK:    encDek,
-			EncryptedSecret: encSecret,
-		}
-	}
-	return encSecrets, nil
+	return sailortest.EncryptSecrets(ak, sk, secrets)
 }
 
 func TestVolumeSecretsCorrectData(t *testing.T) {
@@ -152,3 +126,31 @@ func TestVolumeMiscCorrectData(t *testing.T) {
 		t.Errorf("expected %s got %s", "\"misc data content\"", string(misc))
 	}
 }
+
+// newTestConsumer creates a consumer from initOpts and fails the test when it
+// cannot be created
+func newTestConsumer[C any, S any](t *testing.T, initOpts opts.InitOption) *Consumer[C, S] {
+	t.Helper()
+
+	consumer, err := NewConsumer[C, S](initOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+	return consumer
+}
+
+// configPullOnce is a config resource pulled from Sailor once at start
+func configPullOnce() opts.ResourceOption {
+	return opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+		FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+	}
+}
+
+// fallbackConn connects to the server with its fallback files enabled
+func fallbackConn(server *sailortest.Server) *opts.ConnectionOption {
+	conn := server.Connection("test", "test", "ak", "sk")
+	conn.FallbackBaseURL = server.FallbackURL()
+	return conn
+}
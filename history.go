@@ -0,0 +1,249 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// defaultHistorySize is how many versions of a resource are kept when
+// InitOption.HistorySize is not set
+const defaultHistorySize = 5
+
+// HistoryEntry is a version of a resource which was in use at some point
+type HistoryEntry struct {
+	Version string    `json:"version"`
+	Source  Source    `json:"source"`
+	At      time.Time `json:"at"`
+
+	// Current is true for the version in use
+	Current bool `json:"current"`
+}
+
+// historyEntry keeps the decoded value of a version to put it back
+type historyEntry struct {
+	version string
+	source  Source
+	at      time.Time
+	value   any
+}
+
+// resourceHistory is the ring of the last versions of a resource, oldest first
+type resourceHistory struct {
+	entries []historyEntry
+	current string
+
+	// pinned is set by Rollback, fetched versions already in the history are
+	// not applied until a new one arrives or the pin is released
+	pinned bool
+}
+
+func (h *resourceHistory) find(version string) (historyEntry, bool) {
+	for _, e := range h.entries {
+		if e.version == version {
+			return e, true
+		}
+	}
+	return historyEntry{}, false
+}
+
+// record makes the version the newest of the history, dropping the oldest one
+// beyond size
+func (h *resourceHistory) record(e historyEntry, size int) {
+	for i := range h.entries {
+		if h.entries[i].version == e.version {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+
+	h.entries = append(h.entries, e)
+	for len(h.entries) > size {
+		// shift instead of reslicing so the dropped value can be collected
+		last := len(h.entries) - 1
+		copy(h.entries, h.entries[1:])
+		h.entries[last] = historyEntry{}
+		h.entries = h.entries[:last]
+	}
+}
+
+func (c *Consumer[C, S]) historySize() int {
+	if c.opts.HistorySize == 0 {
+		return defaultHistorySize
+	}
+	return c.opts.HistorySize
+}
+
+// storeVersion stores the decoded value of a fetched resource and keeps it in
+// the history. It is not stored when a rollback pinned the resource and the
+// version is one the history already knows, a new version releases the pin.
+func (c *Consumer[C, S]) storeVersion(raw rawResource, value any) (storedResource, bool) {
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+
+	size := c.historySize()
+	if size < 0 {
+		c.storeDecoded(value, raw.kind, raw.name)
+		return c.recordStored(raw), true
+	}
+
+	key := resourceKey{raw.kind, raw.name}
+	if c.history == nil {
+		c.history = map[resourceKey]*resourceHistory{}
+	}
+	h, ok := c.history[key]
+	if !ok {
+		h = &resourceHistory{}
+		c.history[key] = h
+	}
+
+	if h.pinned {
+		if _, known := h.find(raw.version); known {
+			return storedResource{}, false
+		}
+		h.pinned = false
+	}
+
+	c.storeDecoded(value, raw.kind, raw.name)
+	h.record(historyEntry{version: raw.version, source: raw.source, at: time.Now(), value: value}, size)
+	h.current = raw.version
+	return c.recordStored(raw), true
+}
+
+// History returns the versions of the resource kept for Rollback, newest first.
+// name is only used for misc resources.
+func (c *Consumer[C, S]) History(kind opts.ResourceKind, name string) ([]HistoryEntry, error) {
+	if !c.manages(kind, name) {
+		return nil, ErrResourceNotManaged
+	}
+
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+
+	h, ok := c.history[resourceKey{kind, name}]
+	if !ok {
+		return nil, nil
+	}
+
+	entries := make([]HistoryEntry, 0, len(h.entries))
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		e := h.entries[i]
+		entries = append(entries, HistoryEntry{
+			Version: e.version,
+			Source:  e.source,
+			At:      e.at,
+			Current: e.version == h.current,
+		})
+	}
+	return entries, nil
+}
+
+// Rollback puts back a version of the resource from its History and pins it:
+// fetched versions the history already knows, like the one rolled back from,
+// are not applied until a new version arrives or Unpin is called.
+func (c *Consumer[C, S]) Rollback(kind opts.ResourceKind, name, version string) error {
+	if !c.manages(kind, name) {
+		return ErrResourceNotManaged
+	}
+
+	c.historyMu.Lock()
+	h, ok := c.history[resourceKey{kind, name}]
+	var entry historyEntry
+	if ok {
+		entry, ok = h.find(version)
+	}
+	if !ok {
+		c.historyMu.Unlock()
+		return fmt.Errorf("%s %q: %w", resourceKey{kind, name}, version, ErrVersionNotInHistory)
+	}
+
+	// recorded before the lock is released so a concurrent pull cannot record
+	// its version over the one rolled back to
+	c.storeDecoded(entry.value, kind, name)
+	h.current = version
+	h.pinned = true
+	stored := c.recordStored(rawResource{
+		kind:    kind,
+		name:    name,
+		source:  entry.source,
+		version: version,
+		started: time.Now(),
+	})
+	c.historyMu.Unlock()
+
+	c.announceStored(stored, "sailor resource rolled back")
+	return nil
+}
+
+// Unpin releases the pin set by Rollback and puts back the newest version of
+// the history, the last one fetched
+func (c *Consumer[C, S]) Unpin(kind opts.ResourceKind, name string) error {
+	if !c.manages(kind, name) {
+		return ErrResourceNotManaged
+	}
+
+	c.historyMu.Lock()
+	h, ok := c.history[resourceKey{kind, name}]
+	if !ok || !h.pinned {
+		c.historyMu.Unlock()
+		return nil
+	}
+
+	newest := h.entries[len(h.entries)-1]
+	c.storeDecoded(newest.value, kind, name)
+	h.current = newest.version
+	h.pinned = false
+	stored := c.recordStored(rawResource{
+		kind:    kind,
+		name:    name,
+		source:  newest.source,
+		version: newest.version,
+		started: time.Now(),
+	})
+	c.historyMu.Unlock()
+
+	c.announceStored(stored, "sailor resource pin released")
+	return nil
+}
+
+// pinnedResources returns the resources a rollback pinned. It takes historyMu,
+// which is held while recording the state of a stored resource, so it must not
+// be called with statesMu held.
+func (c *Consumer[C, S]) pinnedResources() map[resourceKey]bool {
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+
+	pinned := map[resourceKey]bool{}
+	for key, h := range c.history {
+		if h.pinned {
+			pinned[key] = true
+		}
+	}
+	return pinned
+}
+
+// manages tells if the resource is one of InitOption.Resources
+func (c *Consumer[C, S]) manages(kind opts.ResourceKind, name string) bool {
+	for _, res := range c.opts.Resources {
+		if res.Def.Kind == kind && res.Def.Name == name {
+			return true
+		}
+	}
+	return false
+}
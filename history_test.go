@@ -0,0 +1,207 @@
+package sailor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func publishConfig(t *testing.T, server *sailortest.Server, consumer *Consumer[map[string]string, any], release string) {
+	t.Helper()
+
+	server.SetConfig("test", "test", map[string]string{"release": release})
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertRelease(t *testing.T, consumer *Consumer[map[string]string, any], release string) {
+	t.Helper()
+
+	if config, _ := consumer.Get(); config["release"] != release {
+		t.Errorf("expected release %s, got %v", release, config)
+	}
+}
+
+func TestHistoryRollback(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "good"})
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	publishConfig(t, server, consumer, "bad")
+
+	history, err := consumer.History(opts.CONFIGS, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Version != "v2" || !history[0].Current || history[1].Version != "v1" || history[1].Source != SourcePull {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	var changes []Change
+	consumer.OnChange(func(ch Change) { changes = append(changes, ch) })
+
+	if err := consumer.Rollback(opts.CONFIGS, "", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "good")
+	if len(changes) != 1 || changes[0].Version != "v1" || changes[0].PreviousVersion != "v2" {
+		t.Errorf("expected the rollback to be notified, got %+v", changes)
+	}
+	if st := consumer.Status().Resources[0]; !st.Pinned || st.Version != "v1" {
+		t.Errorf("expected the resource to be pinned at v1, got %+v", st)
+	}
+
+	// the bad version keeps being served but is not applied while pinned
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "good")
+
+	// a new version releases the pin
+	publishConfig(t, server, consumer, "fixed")
+	assertRelease(t, consumer, "fixed")
+	if consumer.Status().Resources[0].Pinned {
+		t.Errorf("expected a new version to release the pin")
+	}
+}
+
+func TestHistoryUnpin(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "good"})
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	publishConfig(t, server, consumer, "bad")
+
+	if err := consumer.Rollback(opts.CONFIGS, "", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.Unpin(opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "bad")
+
+	history, _ := consumer.History(opts.CONFIGS, "")
+	if !history[0].Current || history[0].Version != "v2" {
+		t.Errorf("expected the newest version to be current, got %+v", history)
+	}
+}
+
+func TestHistoryRollbackDuringPull(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "v1"})
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// listeners may read the status while a store is recorded
+	consumer.OnChange(func(Change) { consumer.Status() })
+
+	// the release is named after the version serving it
+	for i := 2; i < 20; i++ {
+		server.SetConfig("test", "test", map[string]string{"release": fmt.Sprintf("v%d", i)})
+
+		pulled := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				consumer.Rollback(opts.CONFIGS, "", "v1")
+				select {
+				case <-pulled:
+					return
+				default:
+				}
+			}
+		}()
+		consumer.Refresh(context.Background(), opts.CONFIGS, "")
+		close(pulled)
+		wg.Wait()
+
+		config, _ := consumer.Get()
+		if st := consumer.Status().Resources[0]; st.Version != config["release"] {
+			t.Fatalf("expected the status to report the version in use %s, got %s", config["release"], st.Version)
+		}
+	}
+}
+
+func TestHistorySize(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:   []opts.ResourceOption{configPullOnce()},
+		Connection:  server.Connection("test", "test", "ak", "sk"),
+		HistorySize: 2,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	publishConfig(t, server, consumer, "2")
+	publishConfig(t, server, consumer, "3")
+
+	history, _ := consumer.History(opts.CONFIGS, "")
+	if len(history) != 2 || history[0].Version != "v3" || history[1].Version != "v2" {
+		t.Errorf("expected the last 2 versions, got %+v", history)
+	}
+
+	if err := consumer.Rollback(opts.CONFIGS, "", "v1"); !errors.Is(err, ErrVersionNotInHistory) {
+		t.Errorf("expected ErrVersionNotInHistory, got %v", err)
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:   []opts.ResourceOption{configPullOnce()},
+		Connection:  server.Connection("test", "test", "ak", "sk"),
+		HistorySize: -1,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	publishConfig(t, server, consumer, "2")
+
+	if history, _ := consumer.History(opts.CONFIGS, ""); len(history) != 0 {
+		t.Errorf("expected no history, got %+v", history)
+	}
+	assertRelease(t, consumer, "2")
+}
+
+func TestHistoryNotManaged(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := consumer.History(opts.SECRETS, ""); !errors.Is(err, ErrResourceNotManaged) {
+		t.Errorf("expected ErrResourceNotManaged, got %v", err)
+	}
+	if err := consumer.Rollback(opts.MISC, "certs", "1"); !errors.Is(err, ErrResourceNotManaged) {
+		t.Errorf("expected ErrResourceNotManaged, got %v", err)
+	}
+}
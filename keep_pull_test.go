@@ -50,6 +50,7 @@ func TestKeepPullingResource(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err := consumer.Start(); err != nil {
 		t.Error(err)
@@ -120,6 +121,7 @@ func TestKeepPullingResourceSecrets(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err := consumer.Start(); err != nil {
 		t.Error(err)
@@ -177,6 +179,7 @@ func TestKeepPullingResourceMisc(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err := consumer.Start(); err != nil {
 		t.Error(err)
@@ -242,6 +245,7 @@ func TestKeepPullingResourceErrors(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err := consumer.Start(); err != nil {
 		t.Error(err)
@@ -17,8 +17,11 @@ package sailor
 
 import (
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/sailorhq/sailor-go/pkg/opts"
 )
@@ -26,6 +29,9 @@ import (
 type localSailorEnv struct {
 	Name string `json:"name"`
 	Host string `json:"host"`
+
+	// Token is used for this env instead of the top level token when set
+	Token string `json:"token,omitempty"`
 }
 
 type localSailorConfig struct {
@@ -37,15 +43,32 @@ type localSailorConfig struct {
 	User  string `json:"user"`
 }
 
-func buildConnectionFromLocalConfig(base *opts.ConnectionOption) (*opts.ConnectionOption, error) {
+// localConfigPath is ~/.sailor/config unless SAILOR_CONFIG points elsewhere
+func localConfigPath() (string, error) {
+	if path := os.Getenv(ENV_SAILOR_CONFIG); path != "" {
+		return path, nil
+	}
+
 	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sailor", "config"), nil
+}
+
+// buildConnectionFromLocalConfig connects to an env of the local config, which
+// is SAILOR_ENV, else base.Env, else the env active in the CLI
+func buildConnectionFromLocalConfig(base *opts.ConnectionOption) (*opts.ConnectionOption, error) {
+	configPath, err := localConfigPath()
 	if err != nil {
 		return nil, ErrLocalConfigNotFound
 	}
 
-	configPath := filepath.Join(home, ".sailor", "config")
 	data, err := os.ReadFile(configPath)
 	if err != nil {
+		if os.Getenv(ENV_SAILOR_CONFIG) != "" {
+			return nil, fmt.Errorf("%s from %s: %w", configPath, ENV_SAILOR_CONFIG, ErrLocalConfigNotFound)
+		}
 		return nil, ErrLocalConfigNotFound
 	}
 
@@ -54,22 +77,75 @@ func buildConnectionFromLocalConfig(base *opts.ConnectionOption) (*opts.Connecti
 		return nil, ErrLocalConfigInvalid
 	}
 
-	var host string
-	for _, env := range cfg.Manifest.Envs {
-		if env.Name == cfg.Env {
-			host = env.Host
-			break
+	envName := cfg.Env
+	if base.Env != "" {
+		envName = base.Env
+	}
+	if env := os.Getenv(ENV_SAILOR_ENV); env != "" {
+		envName = env
+	}
+
+	var selected *localSailorEnv
+	available := make([]string, 0, len(cfg.Manifest.Envs))
+	for i, env := range cfg.Manifest.Envs {
+		available = append(available, env.Name)
+		if env.Name == envName && selected == nil {
+			selected = &cfg.Manifest.Envs[i]
+		}
+	}
+	if selected == nil || selected.Host == "" {
+		if len(available) == 0 {
+			return nil, fmt.Errorf("%w: %q requested but the manifest has no envs, run 'sailor login'", ErrLocalConfigEnvNotFound, envName)
 		}
+		return nil, fmt.Errorf("%w: %q requested, available envs: %s", ErrLocalConfigEnvNotFound, envName, strings.Join(available, ", "))
 	}
-	if host == "" {
-		return nil, ErrLocalConfigEnvNotFound
+
+	token := cfg.Token
+	if selected.Token != "" {
+		token = selected.Token
 	}
 
 	return &opts.ConnectionOption{
 		Namespace: base.Namespace,
 		App:       base.App,
-		Addr:      host,
-		Token:     cfg.Token,
-		Env:       cfg.Env,
+		Addr:      selected.Host,
+		Token:     token,
+		Env:       selected.Name,
+
+		// the keys still seal the DEV cache and fallback files
+		AccessKey: base.AccessKey,
+		SecretKey: base.SecretKey,
+
+		SocketTimeout:   base.SocketTimeout,
+		FallbackBaseURL: base.FallbackBaseURL,
+		CAFile:          base.CAFile,
+		PullInterval:    base.PullInterval,
 	}, nil
 }
+
+// currentToken is the token sent to Sailor, Connection.Token unless it was
+// refreshed from ~/.sailor/config since
+func (c *Consumer[C, S]) currentToken() string {
+	if token := c.token.Load(); token != nil {
+		return *token
+	}
+	return c.opts.Connection.Token
+}
+
+// reloadLocalToken reads ~/.sailor/config again and reports if it holds a token
+// different from the one in use
+func (c *Consumer[C, S]) reloadLocalToken() bool {
+	conn, err := buildConnectionFromLocalConfig(c.opts.Connection)
+	if err != nil {
+		c.log().Warn("sailor cannot reload ~/.sailor/config", slog.Any("error", err))
+		return false
+	}
+
+	if conn.Token == "" || conn.Token == c.currentToken() {
+		return false
+	}
+
+	c.token.Store(&conn.Token)
+	c.log().Info("sailor token refreshed from ~/.sailor/config")
+	return true
+}
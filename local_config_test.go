@@ -2,11 +2,18 @@ package sailor
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/signing"
 )
 
 func writeSailorConfigToHome(t *testing.T, home string, cfg localSailorConfig) {
@@ -71,6 +78,33 @@ func TestBuildConnectionFromLocalConfig_Valid(t *testing.T) {
 	}
 }
 
+func TestLocalConfigKeepsKeys(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+	writeSailorConfigToHome(t, home, sampleConfig())
+
+	base := baseConn()
+	base.AccessKey, base.SecretKey = "ak", "sk"
+	result, err := buildConnectionFromLocalConfig(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AccessKey != "ak" || result.SecretKey != "sk" {
+		t.Errorf("expected the keys to be kept, got %q %q", result.AccessKey, result.SecretKey)
+	}
+
+	// the keys seal the DEV cache
+	_, err = NewConsumer[map[string]string, any](opts.InitOption{
+		Resources:         []opts.ResourceOption{ConfigPullDefault()},
+		Connection:        base,
+		UseSailorConfig:   true,
+		EncryptedFallback: true,
+	})
+	if err != nil {
+		t.Errorf("expected EncryptedFallback to work with ~/.sailor/config, got %v", err)
+	}
+}
+
 func TestBuildConnectionFromLocalConfig_NotFound(t *testing.T) {
 	home := t.TempDir() // empty — no .sailor/config
 	overrideHome(t, home)
@@ -94,11 +128,108 @@ func TestBuildConnectionFromLocalConfig_EnvNotFound(t *testing.T) {
 	writeSailorConfigToHome(t, home, cfg)
 
 	_, err := buildConnectionFromLocalConfig(baseConn())
-	if err != ErrLocalConfigEnvNotFound {
+	if !errors.Is(err, ErrLocalConfigEnvNotFound) {
 		t.Errorf("expected ErrLocalConfigEnvNotFound, got %v", err)
 	}
 }
 
+func TestBuildConnectionFromLocalConfig_EnvNotFoundListsEnvs(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+	writeSailorConfigToHome(t, home, sampleConfig())
+
+	conn := baseConn()
+	conn.Env = "prod"
+	_, err := buildConnectionFromLocalConfig(conn)
+	if !errors.Is(err, ErrLocalConfigEnvNotFound) {
+		t.Fatalf("expected ErrLocalConfigEnvNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"prod"`) || !strings.Contains(err.Error(), "local, sit") {
+		t.Errorf("expected error to name the env and list available envs, got %v", err)
+	}
+}
+
+func TestBuildConnectionFromLocalConfig_EnvOverride(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+	writeSailorConfigToHome(t, home, sampleConfig())
+	t.Setenv(ENV_SAILOR_ENV, "")
+
+	conn := baseConn()
+	conn.Env = "local"
+	result, err := buildConnectionFromLocalConfig(conn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Env != "local" || result.Addr != "http://localhost:7766" {
+		t.Errorf("expected local env, got %s at %s", result.Env, result.Addr)
+	}
+
+	// SAILOR_ENV wins over Connection.Env
+	t.Setenv(ENV_SAILOR_ENV, "sit")
+	result, err = buildConnectionFromLocalConfig(conn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Env != "sit" || result.Addr != "http://sit.example.com" {
+		t.Errorf("expected sit env, got %s at %s", result.Env, result.Addr)
+	}
+}
+
+func TestBuildConnectionFromLocalConfig_PerEnvToken(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+
+	cfg := sampleConfig()
+	cfg.Manifest.Envs[0].Token = "local-token"
+	writeSailorConfigToHome(t, home, cfg)
+
+	conn := baseConn()
+	conn.Env = "local"
+	result, err := buildConnectionFromLocalConfig(conn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Token != "local-token" {
+		t.Errorf("expected env token 'local-token', got '%s'", result.Token)
+	}
+
+	// envs without their own token use the top level one
+	result, err = buildConnectionFromLocalConfig(baseConn())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Token != "test-token" {
+		t.Errorf("expected 'test-token', got '%s'", result.Token)
+	}
+}
+
+func TestBuildConnectionFromLocalConfig_ConfigPathOverride(t *testing.T) {
+	overrideHome(t, t.TempDir())
+
+	other := t.TempDir()
+	writeSailorConfigToHome(t, other, sampleConfig())
+	t.Setenv(ENV_SAILOR_CONFIG, filepath.Join(other, ".sailor", "config"))
+
+	result, err := buildConnectionFromLocalConfig(baseConn())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Addr != "http://sit.example.com" {
+		t.Errorf("expected Addr 'http://sit.example.com', got '%s'", result.Addr)
+	}
+
+	missing := filepath.Join(other, "missing")
+	t.Setenv(ENV_SAILOR_CONFIG, missing)
+	_, err = buildConnectionFromLocalConfig(baseConn())
+	if !errors.Is(err, ErrLocalConfigNotFound) {
+		t.Fatalf("expected ErrLocalConfigNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected error to name %s, got %v", missing, err)
+	}
+}
+
 func TestBuildConnectionFromLocalConfig_InvalidJSON(t *testing.T) {
 	home := t.TempDir()
 	overrideHome(t, home)
@@ -112,3 +243,146 @@ func TestBuildConnectionFromLocalConfig_InvalidJSON(t *testing.T) {
 		t.Errorf("expected ErrLocalConfigInvalid, got %v", err)
 	}
 }
+
+func tokenServer(t *testing.T, validToken string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-token") != validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"app":"test"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newLocalConfigConsumer(t *testing.T, home, host, token string, fetch opts.FetchOption) *Consumer[map[string]string, any] {
+	t.Helper()
+
+	cfg := sampleConfig()
+	cfg.Manifest.Envs = []localSailorEnv{{Name: "sit", Host: host}}
+	cfg.Token = token
+	writeSailorConfigToHome(t, home, cfg)
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: fetch, Once: true},
+			},
+		},
+		Connection:      baseConn(),
+		UseSailorConfig: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+	return consumer
+}
+
+func TestLocalConfigTokenRefreshed(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+	server := tokenServer(t, "fresh-token")
+
+	consumer := newLocalConfigConsumer(t, home, server.URL, "stale-token", opts.PULL)
+
+	// sailor login ran after the consumer was created
+	cfg := sampleConfig()
+	cfg.Manifest.Envs = []localSailorEnv{{Name: "sit", Host: server.URL}}
+	cfg.Token = "fresh-token"
+	writeSailorConfigToHome(t, home, cfg)
+
+	if err := consumer.Start(); err != nil {
+		t.Fatalf("expected the refreshed token to be used, got %v", err)
+	}
+	if config, _ := consumer.Get(); config["app"] != "test" {
+		t.Errorf("unexpected config %v", config)
+	}
+	if consumer.currentToken() != "fresh-token" {
+		t.Errorf("expected the token to be kept, got %s", consumer.currentToken())
+	}
+}
+
+func TestLocalConfigTokenRefreshedSigned(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	verifier := &signing.Verifier{
+		SecretKey: signing.StaticKeys(map[string]string{"ak": "sk"}),
+		UseNonce: func(nonce string, _ time.Time) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[nonce] {
+				return false
+			}
+			seen[nonce] = true
+			return true
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("x-token") != "fresh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"app":"test"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := sampleConfig()
+	cfg.Manifest.Envs = []localSailorEnv{{Name: "sit", Host: server.URL}}
+	cfg.Token = "stale-token"
+	writeSailorConfigToHome(t, home, cfg)
+
+	base := baseConn()
+	base.AccessKey, base.SecretKey = "ak", "sk"
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:       []opts.ResourceOption{configPullOnce()},
+		Connection:      base,
+		UseSailorConfig: true,
+	})
+
+	// sailor login ran after the consumer was created, the retry carries a
+	// nonce of its own
+	cfg.Token = "fresh-token"
+	writeSailorConfigToHome(t, home, cfg)
+
+	if err := consumer.Start(); err != nil {
+		t.Fatalf("expected the retry to be signed again, got %v", err)
+	}
+	if config, _ := consumer.Get(); config["app"] != "test" {
+		t.Errorf("unexpected config %v", config)
+	}
+}
+
+func TestLocalConfigTokenExpired(t *testing.T) {
+	for _, fetch := range []opts.FetchOption{opts.PULL, opts.DEV} {
+		home := t.TempDir()
+		overrideHome(t, home)
+		server := tokenServer(t, "fresh-token")
+
+		consumer := newLocalConfigConsumer(t, home, server.URL, "stale-token", fetch)
+
+		err := consumer.Start()
+		if !errors.Is(err, ErrTokenExpired) {
+			t.Fatalf("expected ErrTokenExpired got %v", err)
+		}
+		if !strings.Contains(err.Error(), "sailor login") {
+			t.Errorf("expected the error to tell what to run, got %v", err)
+		}
+
+		var re *ResourceError
+		if !errors.As(err, &re) || re.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected a 401 ResourceError got %v", err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// headerSailorVersion is the response header through which Sailor announces the
+// version of the resource being served
+const headerSailorVersion = "x-sailor-version"
+
+// Source tells where the value of a resource was loaded from
+type Source string
+
+const (
+	SourceVolume   Source = "volume"
+	SourcePull     Source = "pull"
+	SourceDev      Source = "dev"
+	SourceStream   Source = "stream"
+	SourceFallback Source = "fallback"
+)
+
+// rawResource is the undecoded payload of a resource along with the details
+// of where and when it was fetched
+type rawResource struct {
+	kind    opts.ResourceKind
+	name    string
+	source  Source
+	version string
+	started time.Time
+	data    []byte
+
+	// url is where the resource was fetched from, the file path for resources
+	// read from the filesystem
+	url string
+
+	// endpoint is the Sailor replica which served the resource, empty when the
+	// connection has a single one
+	endpoint string
+
+	// statusCode is the HTTP status the resource was served with, zero for
+	// resources read from the filesystem
+	statusCode int
+}
+
+// newLogger returns the logger the consumer emits its events through. Logging is
+// on by default, when it is turned off every event is discarded.
+func newLogger(initOpts opts.InitOption) *slog.Logger {
+	if initOpts.Logging != nil && !*initOpts.Logging {
+		return slog.New(slog.DiscardHandler)
+	}
+
+	if initOpts.Logger != nil {
+		return initOpts.Logger
+	}
+
+	return slog.Default()
+}
+
+// log returns the consumer logger, a consumer which was not built through
+// NewConsumer does not log anything
+func (c *Consumer[C, S]) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+
+	return c.logger
+}
+
+// resourceAttrs are the common attributes attached to every resource event
+func resourceAttrs(kind opts.ResourceKind, name string, source Source, version string, started time.Time) []any {
+	return []any{
+		slog.String("kind", string(kind)),
+		slog.String("name", name),
+		slog.String("source", string(source)),
+		slog.String("version", version),
+		slog.Duration("duration", time.Since(started)),
+	}
+}
+
+// resourceVersion is the version announced by Sailor, when the payload does not
+// come with one (volume, fallback) we use a short digest of the content
+func resourceVersion(header http.Header, data []byte) string {
+	if header != nil {
+		if v := header.Get(headerSailorVersion); v != "" {
+			return v
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// fetchFailed records a fetch which did not return a resource and returns err
+func (c *Consumer[C, S]) fetchFailed(started time.Time, err *ResourceError) error {
+	if c.metrics != nil {
+		c.metrics.FetchAttempt(string(err.Kind), err.Name, string(err.Source), time.Since(started))
+		if err.StatusCode != 0 {
+			c.metrics.HTTPStatus(string(err.Kind), err.Name, err.StatusCode)
+		}
+	}
+
+	c.recordFailure(err.Kind, err.Name, err.Source, err, time.Now())
+
+	attrs := resourceAttrs(err.Kind, err.Name, err.Source, "", started)
+	if err.StatusCode != 0 {
+		attrs = append(attrs, slog.Int("status", err.StatusCode))
+	}
+	attrs = append(attrs, slog.Any("error", err))
+
+	c.log().Warn("sailor resource fetch failed", attrs...)
+	return err
+}
+
+// fallbackActivated records that the primary source is given up for fallback
+func (c *Consumer[C, S]) fallbackActivated(kind opts.ResourceKind, name string, from Source) {
+	if c.metrics != nil {
+		c.metrics.FallbackActivated(string(kind), name)
+	}
+
+	c.log().Warn("sailor falling back",
+		slog.String("kind", string(kind)),
+		slog.String("name", name),
+		slog.String("from", string(from)),
+	)
+}
+
+// applyResource decodes and stores the raw resource, emitting a structured
+// event and metrics for the outcome
+func (c *Consumer[C, S]) applyResource(raw rawResource, msg string) error {
+	if c.metrics != nil {
+		c.metrics.FetchAttempt(string(raw.kind), raw.name, string(raw.source), time.Since(raw.started))
+		if raw.statusCode != 0 {
+			c.metrics.HTTPStatus(string(raw.kind), raw.name, raw.statusCode)
+		}
+	}
+
+	attrs := resourceAttrs(raw.kind, raw.name, raw.source, raw.version, raw.started)
+	value, cause := c.decodeRawResource(raw.data, raw.kind)
+	if cause != nil {
+		stage := StageDecode
+		var de *decryptError
+		if errors.As(cause, &de) {
+			stage = StageDecrypt
+		}
+		err := &ResourceError{
+			Kind:       raw.kind,
+			Name:       raw.name,
+			Source:     raw.source,
+			Stage:      stage,
+			URL:        redactURL(raw.url),
+			StatusCode: raw.statusCode,
+			Err:        cause,
+		}
+
+		if c.metrics != nil {
+			c.metrics.DecodeFailure(string(raw.kind), raw.name)
+		}
+		c.recordFailure(raw.kind, raw.name, raw.source, err, time.Now())
+		c.log().Error("sailor resource decode failed", append(attrs, slog.Any("error", err))...)
+		return err
+	}
+
+	stored, ok := c.storeVersion(raw, value)
+	if !ok {
+		c.recordFetched(raw.kind, raw.name, time.Now())
+		c.log().Debug("sailor resource pinned by rollback, fetched version not applied", attrs...)
+		return nil
+	}
+
+	c.announceStored(stored, msg)
+	return nil
+}
+
+// resourceConfirmed records a fetch which returned the version in use without
+// storing it again, the resource is kept fresh in Status and in metrics
+func (c *Consumer[C, S]) resourceConfirmed(raw rawResource) {
+	now := time.Now()
+	if c.metrics != nil {
+		c.metrics.FetchAttempt(string(raw.kind), raw.name, string(raw.source), now.Sub(raw.started))
+		if raw.statusCode != 0 {
+			c.metrics.HTTPStatus(string(raw.kind), raw.name, raw.statusCode)
+		}
+	}
+	if fm, ok := c.metrics.(metrics.FreshnessMetrics); ok {
+		fm.Unchanged(string(raw.kind), raw.name, now)
+	}
+	c.servedBy(raw.kind, raw.name, raw.endpoint)
+	c.recordFetched(raw.kind, raw.name, now)
+}
+
+// storedResource is a resource value which was just stored along with the
+// version it replaced
+type storedResource struct {
+	raw             rawResource
+	at              time.Time
+	previousVersion string
+	wasLoaded       bool
+}
+
+// resourceStored records a resource value which was just stored and announces
+// it
+func (c *Consumer[C, S]) resourceStored(raw rawResource, msg string) {
+	c.announceStored(c.recordStored(raw), msg)
+}
+
+// recordStored records the version of a resource value which was just stored.
+// It is called along with the store, under historyMu when the history is
+// kept, so the recorded version is always the one in use.
+func (c *Consumer[C, S]) recordStored(raw rawResource) storedResource {
+	now := time.Now()
+	c.servedBy(raw.kind, raw.name, raw.endpoint)
+	previousVersion, wasLoaded := c.recordSuccess(raw, now)
+	c.signalStored()
+
+	return storedResource{raw: raw, at: now, previousVersion: previousVersion, wasLoaded: wasLoaded}
+}
+
+// announceStored counts and logs a recorded value and notifies the OnChange
+// listeners, only when its version changed: a pull of the version in use just
+// keeps it fresh. No lock is held so listeners may call back into the consumer.
+func (c *Consumer[C, S]) announceStored(stored storedResource, msg string) {
+	raw := stored.raw
+	if stored.wasLoaded && stored.previousVersion == raw.version {
+		if fm, ok := c.metrics.(metrics.FreshnessMetrics); ok {
+			fm.Unchanged(string(raw.kind), raw.name, stored.at)
+		}
+		return
+	}
+
+	if c.metrics != nil {
+		c.metrics.Reloaded(string(raw.kind), raw.name, raw.version, stored.at)
+	}
+
+	attrs := resourceAttrs(raw.kind, raw.name, raw.source, raw.version, raw.started)
+	if raw.endpoint != "" {
+		attrs = append(attrs, slog.String("endpoint", raw.endpoint))
+	}
+	c.log().Info(msg, attrs...)
+
+	c.reportChanged()
+	c.notifyChange(Change{
+		Kind:            raw.kind,
+		Name:            raw.name,
+		Source:          raw.source,
+		Version:         raw.version,
+		PreviousVersion: stored.previousVersion,
+	})
+}
@@ -0,0 +1,127 @@
+package sailor
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestLoggingEmitsResourceEvents(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "sailor"})
+
+	// logging is on unless turned off
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Logger:     logger,
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("expected a single JSON event, got %q: %v", buf.String(), err)
+	}
+
+	if event["msg"] != "sailor resource loaded" {
+		t.Errorf("unexpected msg %v", event["msg"])
+	}
+
+	for key, want := range map[string]string{"kind": "config", "source": "pull", "version": "v1"} {
+		if event[key] != want {
+			t.Errorf("expected %s=%s got %v", key, want, event[key])
+		}
+	}
+
+	if _, ok := event["duration"]; !ok {
+		t.Error("expected duration attribute")
+	}
+}
+
+func TestLoggingDisabledIsSilent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "sailor"})
+
+	logging := false
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Logging:    &logging,
+		Logger:     logger,
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no output with Logging=false, got %q", buf.String())
+	}
+}
+
+func TestResourceVersion(t *testing.T) {
+	header := http.Header{}
+	header.Set(headerSailorVersion, "v42")
+	if v := resourceVersion(header, []byte("x")); v != "v42" {
+		t.Errorf("expected v42 got %s", v)
+	}
+
+	if v := resourceVersion(nil, []byte("x")); !strings.HasPrefix(v, "sha256:") {
+		t.Errorf("expected digest version got %s", v)
+	}
+}
+
+func TestLoggingDevEvents(t *testing.T) {
+	overrideHome(t, t.TempDir())
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "sailor"})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Logger: logger,
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.DEV},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	var cached map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event map[string]any
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("expected JSON events, got %q: %v", line, err)
+		}
+		if event["msg"] == "sailor dev resource cached" {
+			cached = event
+		}
+	}
+	if cached == nil {
+		t.Fatalf("expected a dev cached event, got %s", buf.String())
+	}
+	for key, want := range map[string]string{"kind": "config", "source": "dev"} {
+		if cached[key] != want {
+			t.Errorf("expected %s=%s got %v", key, want, cached[key])
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sailor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestMetricsRecordFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/test-config.sailor.fall" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"app":"fallback"}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	os.Setenv(ENV_SAILOR_FALLBACK_BASE_URL, server.URL)
+	defer os.Unsetenv(ENV_SAILOR_FALLBACK_BASE_URL)
+
+	registry := metrics.NewRegistry()
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Metrics: registry,
+		Resources: []opts.ResourceOption{
+			{
+				Def: opts.ResourceDefinition{
+					Kind: opts.CONFIGS,
+				},
+				FetchDef: opts.FetchDefinition{
+					Fetch: opts.PULL,
+					Once:  true,
+				},
+				FallbackEnabled: true,
+			},
+		},
+		Connection: &opts.ConnectionOption{
+			Addr:          server.URL,
+			Namespace:     "test",
+			App:           "test",
+			AccessKey:     "ak",
+			SecretKey:     "sk",
+			SocketTimeout: time.Second * 5,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots := registry.Snapshot()
+	if len(snapshots) != 1 {
+		t.Fatalf("expected a single resource got %+v", snapshots)
+	}
+
+	s := snapshots[0]
+	if s.FetchAttempts["pull"] != 1 || s.FetchAttempts["fallback"] != 1 {
+		t.Errorf("expected one pull and one fallback attempt got %+v", s.FetchAttempts)
+	}
+	if s.HTTPStatuses[http.StatusServiceUnavailable] != 1 || s.HTTPStatuses[http.StatusOK] != 1 {
+		t.Errorf("unexpected status codes %+v", s.HTTPStatuses)
+	}
+	if s.FallbackActivated != 1 || s.Reloads != 1 || s.LastSuccess.IsZero() {
+		t.Errorf("unexpected snapshot %+v", s)
+	}
+}
+
+func TestMetricsCountOnlyChangedVersions(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "sailor"})
+
+	registry := metrics.NewRegistry()
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Metrics: registry,
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 10 * time.Millisecond},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	loadedAt := registry.Snapshot()[0].LastSuccess
+	sailortest.Eventually(t, time.Second, func() bool {
+		return registry.Snapshot()[0].LastSuccess.After(loadedAt)
+	})
+	if s := registry.Snapshot()[0]; s.Reloads != 1 {
+		t.Errorf("expected pulls of the same version not to count as reloads, got %d", s.Reloads)
+	}
+
+	server.SetConfig("test", "test", map[string]string{"app": "changed"})
+	sailortest.Eventually(t, time.Second, func() bool {
+		return registry.Snapshot()[0].Reloads == 2
+	})
+}
@@ -0,0 +1,46 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import "github.com/sailorhq/sailor-go/pkg/opts"
+
+// Change describes a resource whose value was replaced by a different version
+type Change struct {
+	Kind            opts.ResourceKind
+	Name            string
+	Source          Source
+	Version         string
+	PreviousVersion string
+}
+
+// OnChange registers fn to be called every time a resource is stored with a
+// version different from the one in use. fn is called synchronously from the
+// goroutine which loaded the resource and must not block.
+func (c *Consumer[C, S]) OnChange(fn func(Change)) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+func (c *Consumer[C, S]) notifyChange(change Change) {
+	c.listenersMu.RLock()
+	listeners := c.listeners
+	c.listenersMu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(change)
+	}
+}
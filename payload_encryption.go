@@ -0,0 +1,66 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sailor
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sailorhq/sailor-go/pkg/envelope"
+)
+
+// openPayload decrypts a fallback or cached payload when
+// InitOption.EncryptedFallback is set and returns it untouched otherwise
+func (c *Consumer[C, S]) openPayload(b []byte) ([]byte, error) {
+	if !c.opts.EncryptedFallback {
+		return b, nil
+	}
+
+	conn := c.opts.Connection
+	payload, err := envelope.Open(conn.AccessKey, conn.SecretKey, b)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open encrypted payload: %w", err)
+	}
+	return payload, nil
+}
+
+// sealPayload encrypts a payload about to be cached when
+// InitOption.EncryptedFallback is set and returns it untouched otherwise
+func (c *Consumer[C, S]) sealPayload(b []byte) ([]byte, error) {
+	if !c.opts.EncryptedFallback {
+		return b, nil
+	}
+
+	conn := c.opts.Connection
+	return envelope.Seal(conn.AccessKey, conn.SecretKey, b)
+}
+
+// readDevCache returns the cached payload of a DEV resource, a copy which
+// cannot be opened, like one cached before EncryptedFallback was turned on,
+// counts as not cached
+func (c *Consumer[C, S]) readDevCache(cachePath string) ([]byte, bool) {
+	b, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+
+	payload, err := c.openPayload(b)
+	if err != nil {
+		return nil, false
+	}
+	return payload, true
+}
@@ -0,0 +1,205 @@
+package sailor
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/envelope"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestEncryptedFallback(t *testing.T) {
+	t.Setenv(ENV_SAILOR_FALLBACK_BASE_URL, "")
+
+	server := sailortest.NewServer(t)
+	server.SetStatus(503)
+
+	config, err := sailortest.EncryptFallback("ak", "sk", map[string]string{"release": "fallback"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs, err := sailortest.EncryptFallback("ak", "sk", []byte{0x00, 0xff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetFallback("test", opts.CONFIGS, config)
+	server.SetFallback("test", opts.MISC, certs)
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{
+			configPullOnce(),
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection:        fallbackConn(server),
+		EncryptedFallback: true,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertRelease(t, consumer, "fallback")
+	if b, _ := consumer.GetMisc("certs"); !bytes.Equal(b, []byte{0x00, 0xff}) {
+		t.Errorf("expected the misc fallback to be decrypted, got %v", b)
+	}
+}
+
+func TestEncryptedFallbackRejected(t *testing.T) {
+	t.Setenv(ENV_SAILOR_FALLBACK_BASE_URL, "")
+
+	server := sailortest.NewServer(t)
+	server.SetStatus(503)
+
+	assertDecryptFailed := func(err error, target error) {
+		t.Helper()
+
+		var re *ResourceError
+		if !errors.As(err, &re) || re.Fallback == nil {
+			t.Fatalf("expected the pull failure with its fallback, got %v", err)
+		}
+		var fallbackErr *ResourceError
+		if !errors.As(re.Fallback, &fallbackErr) || fallbackErr.Stage != StageDecrypt || fallbackErr.Source != SourceFallback {
+			t.Errorf("expected the fallback to fail at decrypt, got %v", re.Fallback)
+		}
+		if target != nil && !errors.Is(err, target) {
+			t.Errorf("expected %v, got %v", target, err)
+		}
+	}
+
+	// a plaintext file is not accepted once fallbacks are encrypted
+	server.SetFallback("test", opts.CONFIGS, []byte(`{"release": "fallback"}`))
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:         []opts.ResourceOption{configPullOnce()},
+		Connection:        fallbackConn(server),
+		EncryptedFallback: true,
+	})
+	assertDecryptFailed(consumer.Start(), ErrPayloadNotEncrypted)
+
+	// neither is one sealed for other keys
+	config, _ := sailortest.EncryptFallback("ak", "other", map[string]string{"release": "fallback"})
+	server.SetFallback("test", opts.CONFIGS, config)
+	consumer = newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:         []opts.ResourceOption{configPullOnce()},
+		Connection:        fallbackConn(server),
+		EncryptedFallback: true,
+	})
+	assertDecryptFailed(consumer.Start(), nil)
+}
+
+func TestEncryptedDevCache(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	devConfig := opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+		FetchDef: opts.FetchDefinition{Fetch: opts.DEV},
+	}
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:         []opts.ResourceOption{devConfig},
+		Connection:        server.Connection("test", "test", "ak", "sk"),
+		EncryptedFallback: true,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "1")
+
+	matches, _ := filepath.Glob(filepath.Join(home, ".sailor", "cache", "*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one cached payload, got %v", matches)
+	}
+	cached, _ := os.ReadFile(matches[0])
+	if bytes.Contains(cached, []byte("release")) {
+		t.Errorf("expected the cached payload to be encrypted, got %s", cached)
+	}
+	if payload, err := envelope.Open("ak", "sk", cached); err != nil || !bytes.Contains(payload, []byte(`"release":"1"`)) {
+		t.Errorf("expected the cached payload to open, got %s %v", payload, err)
+	}
+
+	// the encrypted cache is served without asking Sailor
+	server.SetConfig("test", "test", map[string]string{"release": "2"})
+	consumer = newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:         []opts.ResourceOption{devConfig},
+		Connection:        server.Connection("test", "test", "ak", "sk"),
+		EncryptedFallback: true,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "1")
+
+	// a plaintext copy from before encryption was on is fetched again
+	os.WriteFile(matches[0], []byte(`{"release":"plain"}`), 0644)
+	consumer = newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:         []opts.ResourceOption{devConfig},
+		Connection:        server.Connection("test", "test", "ak", "sk"),
+		EncryptedFallback: true,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "2")
+}
+
+func TestEncryptedDevCacheWatched(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{{
+			Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+			FetchDef: opts.FetchDefinition{Fetch: opts.DEV},
+		}},
+		Connection:        server.Connection("test", "test", "ak", "sk"),
+		EncryptedFallback: true,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(home, ".sailor", "cache", "*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one cached payload, got %v", matches)
+	}
+
+	// a sealed payload written to the cache is opened before being applied
+	sealed, err := envelope.Seal("ak", "sk", []byte(`{"release":"2"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(matches[0], sealed, 0644)
+	sailortest.Eventually(t, 3*time.Second, func() bool {
+		config, _ := consumer.Get()
+		return config["release"] == "2"
+	})
+
+	// a payload which does not open is skipped
+	os.WriteFile(matches[0], []byte(`{"release":"plain"}`), 0644)
+	time.Sleep(1500 * time.Millisecond)
+	assertRelease(t, consumer, "2")
+}
+
+func TestEncryptedFallbackNeedsKeys(t *testing.T) {
+	server := sailortest.NewServer(t)
+
+	_, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources:         []opts.ResourceOption{ConfigPullDefault()},
+		Connection:        &opts.ConnectionOption{Addr: server.URL, Namespace: "test", App: "test"},
+		EncryptedFallback: true,
+	})
+	if !errors.Is(err, ErrPayloadNoCredentials) {
+		t.Errorf("expected ErrPayloadNoCredentials, got %v", err)
+	}
+}
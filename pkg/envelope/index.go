@@ -0,0 +1,113 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package envelope encrypts whole resource payloads with the KEK/DEK scheme of
+// the vault package, the KEK being derived from the SecretKey and AccessKey of
+// the app. It is used for fallback files and cached payloads of any kind.
+//
+// A sealed payload is a JSON object:
+//
+//	{"sailor_envelope":"v1","encrypted_secret":"<base64>","encrypted_dek":"<base64>"}
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sailorhq/sailor/pkg/vault"
+)
+
+// Version is the envelope format written by Seal
+const Version = "v1"
+
+var (
+	ErrNotSealed          = errors.New("payload is not a sailor envelope")
+	ErrUnsupportedVersion = errors.New("sailor envelope version is not supported")
+	ErrNoCredentials      = errors.New("sealing and opening envelopes needs an AccessKey and a SecretKey")
+)
+
+type sealed struct {
+	Envelope string `json:"sailor_envelope"`
+	vault.SecretRecord
+}
+
+// Seal encrypts the payload with a fresh DEK, itself encrypted with the KEK of
+// the app keys
+func Seal(accessKey, secretKey string, payload []byte) ([]byte, error) {
+	kek, err := deriveKEK(accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := vault.GenerateDEK()
+	if err != nil {
+		return nil, err
+	}
+
+	encPayload, _, err := vault.EncryptWithDEK(string(payload), dek)
+	if err != nil {
+		return nil, err
+	}
+
+	encDEK, err := vault.EncryptDEK(dek, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(sealed{
+		Envelope: Version,
+		SecretRecord: vault.SecretRecord{
+			EncryptedSecret: encPayload,
+			EncryptedDEK:    encDEK,
+		},
+	})
+}
+
+// Open decrypts a payload sealed with the same app keys, anything else than an
+// envelope fails with ErrNotSealed
+func Open(accessKey, secretKey string, b []byte) ([]byte, error) {
+	var env sealed
+	if err := json.Unmarshal(b, &env); err != nil || env.Envelope == "" {
+		return nil, ErrNotSealed
+	}
+	if env.Envelope != Version {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedVersion, env.Envelope)
+	}
+
+	kek, err := deriveKEK(accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := vault.DecryptDEK(env.EncryptedDEK, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := vault.DecryptWithDEK(env.EncryptedSecret, dek)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(payload), nil
+}
+
+func deriveKEK(accessKey, secretKey string) ([]byte, error) {
+	if accessKey == "" || secretKey == "" {
+		return nil, ErrNoCredentials
+	}
+	return vault.DeriveKEK(secretKey, []byte(accessKey))
+}
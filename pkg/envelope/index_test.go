@@ -0,0 +1,47 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	for _, payload := range [][]byte{[]byte(`{"db_host":"10.0.0.1"}`), {0x00, 0xff, 0xfe, 0x80}, {}} {
+		b, err := Seal("ak", "sk", payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(payload) > 0 && bytes.Contains(b, payload) {
+			t.Errorf("expected the payload not to appear in %s", b)
+		}
+
+		opened, err := Open("ak", "sk", b)
+		if err != nil || !bytes.Equal(opened, payload) {
+			t.Errorf("expected %q back got %q %v", payload, opened, err)
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	b, err := Seal("ak", "sk", []byte(`{"app":"test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open("ak", "other", b); err == nil {
+		t.Error("expected other keys to fail opening the envelope")
+	}
+	if _, err := Open("ak", "sk", []byte(`{"app":"test"}`)); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("expected ErrNotSealed for plaintext JSON got %v", err)
+	}
+	if _, err := Open("ak", "sk", []byte("plain text")); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("expected ErrNotSealed for plain text got %v", err)
+	}
+	if _, err := Open("ak", "sk", []byte(`{"sailor_envelope":"v9"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion got %v", err)
+	}
+	if _, err := Seal("", "sk", nil); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials got %v", err)
+	}
+}
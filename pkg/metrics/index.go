@@ -0,0 +1,306 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package metrics records how the Sailor Client is doing at pulling and
+// reloading its resources.
+package metrics
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives the measurements of a consumer. Every method is keyed by the
+// resource kind (config, secret, misc) and the resource name.
+type Metrics interface {
+	// FetchAttempt is called once per attempt to read a resource from a source
+	// (volume, pull, dev, fallback) with the time it took
+	FetchAttempt(kind, name, source string, took time.Duration)
+
+	// HTTPStatus is called with the status code of every Sailor/fallback response
+	HTTPStatus(kind, name string, code int)
+
+	// FallbackActivated is called when the primary source is given up for fallback
+	FallbackActivated(kind, name string)
+
+	// DecodeFailure is called when a fetched resource cannot be decoded or decrypted
+	DecodeFailure(kind, name string)
+
+	// Reloaded is called when a resource was stored with a new version
+	Reloaded(kind, name, version string, at time.Time)
+}
+
+// FreshnessMetrics is implemented by Metrics which also want to know when a
+// fetch confirmed the version in use without changing it
+type FreshnessMetrics interface {
+	// Unchanged is called when a fetched resource has the version in use
+	Unchanged(kind, name string, at time.Time)
+}
+
+// EndpointMetrics is implemented by Metrics which also want to know which
+// Sailor endpoint served each resource when the consumer fails over across
+// several of them
+type EndpointMetrics interface {
+	// ServedBy is called with the endpoint a resource was fetched from
+	ServedBy(kind, name, endpoint string)
+}
+
+// CircuitMetrics is implemented by Metrics which also want to follow the
+// circuit breaker of the consumer
+type CircuitMetrics interface {
+	// CircuitState is called every time the circuit changes state, to closed,
+	// open or half-open
+	CircuitState(state string, at time.Time)
+}
+
+// latencyBuckets are the upper bounds (in seconds) of the fetch latency histogram
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type resourceKey struct {
+	Kind string
+	Name string
+}
+
+type fetchKey struct {
+	resourceKey
+	Source string
+}
+
+type statusKey struct {
+	resourceKey
+	Code int
+}
+
+type histogram struct {
+	// counts are cumulative per bucket in latencyBuckets
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	for i, b := range latencyBuckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Registry is an in-memory Metrics implementation which can be exported through
+// expvar or scraped in the Prometheus text format.
+type Registry struct {
+	mu sync.Mutex
+
+	attempts    map[fetchKey]uint64
+	latency     map[fetchKey]*histogram
+	statuses    map[statusKey]uint64
+	fallbacks   map[resourceKey]uint64
+	failures    map[resourceKey]uint64
+	reloads     map[resourceKey]uint64
+	lastSuccess map[resourceKey]time.Time
+	versions    map[resourceKey]string
+	endpoints   map[resourceKey]string
+
+	circuitState  string
+	circuitSince  time.Time
+	circuitOpened uint64
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		attempts:    map[fetchKey]uint64{},
+		latency:     map[fetchKey]*histogram{},
+		statuses:    map[statusKey]uint64{},
+		fallbacks:   map[resourceKey]uint64{},
+		failures:    map[resourceKey]uint64{},
+		reloads:     map[resourceKey]uint64{},
+		lastSuccess: map[resourceKey]time.Time{},
+		versions:    map[resourceKey]string{},
+		endpoints:   map[resourceKey]string{},
+	}
+}
+
+func (r *Registry) FetchAttempt(kind, name, source string, took time.Duration) {
+	key := fetchKey{resourceKey{kind, name}, source}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[key]++
+	h, ok := r.latency[key]
+	if !ok {
+		h = &histogram{}
+		r.latency[key] = h
+	}
+	h.observe(took.Seconds())
+}
+
+func (r *Registry) HTTPStatus(kind, name string, code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses[statusKey{resourceKey{kind, name}, code}]++
+}
+
+func (r *Registry) FallbackActivated(kind, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallbacks[resourceKey{kind, name}]++
+}
+
+func (r *Registry) DecodeFailure(kind, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[resourceKey{kind, name}]++
+}
+
+func (r *Registry) Reloaded(kind, name, version string, at time.Time) {
+	key := resourceKey{kind, name}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reloads[key]++
+	r.lastSuccess[key] = at
+	r.versions[key] = version
+}
+
+// Unchanged keeps the last success time fresh, staleness is about the value
+// being confirmed rather than changed
+func (r *Registry) Unchanged(kind, name string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSuccess[resourceKey{kind, name}] = at
+}
+
+func (r *Registry) ServedBy(kind, name, endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints[resourceKey{kind, name}] = endpoint
+}
+
+func (r *Registry) CircuitState(state string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.circuitState = state
+	r.circuitSince = at
+	if state == "open" {
+		r.circuitOpened++
+	}
+}
+
+// CircuitSnapshot is a point in time copy of the circuit breaker metrics, State
+// is empty when the consumer has no circuit breaker
+type CircuitSnapshot struct {
+	State  string    `json:"state"`
+	Since  time.Time `json:"since"`
+	Opened uint64    `json:"opened"`
+}
+
+// Circuit returns the current state of the circuit breaker
+func (r *Registry) Circuit() CircuitSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return CircuitSnapshot{State: r.circuitState, Since: r.circuitSince, Opened: r.circuitOpened}
+}
+
+// ResourceSnapshot is a point in time copy of the metrics of a single resource
+type ResourceSnapshot struct {
+	Kind              string            `json:"kind"`
+	Name              string            `json:"name"`
+	FetchAttempts     map[string]uint64 `json:"fetch_attempts"`
+	HTTPStatuses      map[int]uint64    `json:"http_statuses"`
+	FallbackActivated uint64            `json:"fallback_activations"`
+	DecodeFailures    uint64            `json:"decode_failures"`
+	Reloads           uint64            `json:"reloads"`
+	LastSuccess       time.Time         `json:"last_success"`
+	Version           string            `json:"version"`
+	Endpoint          string            `json:"endpoint,omitempty"`
+}
+
+// Snapshot returns the current metrics of every resource sorted by kind and name
+func (r *Registry) Snapshot() []ResourceSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byKey := map[resourceKey]*ResourceSnapshot{}
+	get := func(key resourceKey) *ResourceSnapshot {
+		s, ok := byKey[key]
+		if !ok {
+			s = &ResourceSnapshot{
+				Kind:          key.Kind,
+				Name:          key.Name,
+				FetchAttempts: map[string]uint64{},
+				HTTPStatuses:  map[int]uint64{},
+			}
+			byKey[key] = s
+		}
+		return s
+	}
+
+	for k, v := range r.attempts {
+		get(k.resourceKey).FetchAttempts[k.Source] = v
+	}
+	for k, v := range r.statuses {
+		get(k.resourceKey).HTTPStatuses[k.Code] = v
+	}
+	for k, v := range r.fallbacks {
+		get(k).FallbackActivated = v
+	}
+	for k, v := range r.failures {
+		get(k).DecodeFailures = v
+	}
+	for k, v := range r.reloads {
+		get(k).Reloads = v
+	}
+	for k, v := range r.lastSuccess {
+		get(k).LastSuccess = v
+	}
+	for k, v := range r.versions {
+		get(k).Version = v
+	}
+	for k, v := range r.endpoints {
+		get(k).Endpoint = v
+	}
+
+	snapshots := make([]ResourceSnapshot, 0, len(byKey))
+	for _, s := range byKey {
+		snapshots = append(snapshots, *s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Kind != snapshots[j].Kind {
+			return snapshots[i].Kind < snapshots[j].Kind
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	return snapshots
+}
+
+// Expvar adapts the registry to an expvar.Var which renders Snapshot as JSON
+func (r *Registry) Expvar() expvar.Var {
+	return expvar.Func(func() any { return r.Snapshot() })
+}
+
+// PublishExpvar publishes the registry under the given name in /debug/vars
+func (r *Registry) PublishExpvar(name string) {
+	expvar.Publish(name, r.Expvar())
+}
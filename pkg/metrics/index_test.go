@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistrySnapshot(t *testing.T) {
+	r := NewRegistry()
+	r.FetchAttempt("config", "", "pull", 20*time.Millisecond)
+	r.FetchAttempt("config", "", "pull", 20*time.Millisecond)
+	r.HTTPStatus("config", "", 500)
+	r.FallbackActivated("config", "")
+	r.DecodeFailure("secret", "")
+	r.Reloaded("config", "", "v3", time.Unix(1700000000, 0))
+
+	snapshots := r.Snapshot()
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 resources got %d", len(snapshots))
+	}
+
+	config := snapshots[0]
+	if config.Kind != "config" || config.FetchAttempts["pull"] != 2 || config.HTTPStatuses[500] != 1 ||
+		config.FallbackActivated != 1 || config.Reloads != 1 || config.Version != "v3" {
+		t.Errorf("unexpected config snapshot %+v", config)
+	}
+
+	if snapshots[1].Kind != "secret" || snapshots[1].DecodeFailures != 1 {
+		t.Errorf("unexpected secret snapshot %+v", snapshots[1])
+	}
+}
+
+func TestRegistryPrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.FetchAttempt("misc", "certs", "pull", 20*time.Millisecond)
+	r.HTTPStatus("misc", "certs", 200)
+	r.Reloaded("misc", "certs", `v"1`, time.Unix(1700000000, 0))
+	r.ServedBy("misc", "certs", "https://sailor-b.example")
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`sailor_fetch_attempts_total{kind="misc",name="certs",source="pull"} 1`,
+		`sailor_fetch_duration_seconds_bucket{kind="misc",name="certs",source="pull",le="0.01"} 0`,
+		`sailor_fetch_duration_seconds_bucket{kind="misc",name="certs",source="pull",le="0.025"} 1`,
+		`sailor_fetch_duration_seconds_count{kind="misc",name="certs",source="pull"} 1`,
+		`sailor_http_responses_total{kind="misc",name="certs",code="200"} 1`,
+		`sailor_reloads_total{kind="misc",name="certs"} 1`,
+		`sailor_last_success_timestamp_seconds{kind="misc",name="certs"} 1700000000`,
+		`sailor_resource_version_info{kind="misc",name="certs",version="v\"1"} 1`,
+		`sailor_resource_endpoint_info{kind="misc",name="certs",endpoint="https://sailor-b.example"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %s\n%s", want, out)
+		}
+	}
+}
+
+// stalledWriter blocks every write until release is closed
+type stalledWriter struct {
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	close(w.writing)
+	<-w.release
+	return len(p), nil
+}
+
+func TestRegistryPrometheusSlowScraper(t *testing.T) {
+	r := NewRegistry()
+	r.FetchAttempt("config", "", "pull", time.Millisecond)
+
+	w := &stalledWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	written := make(chan error)
+	go func() { written <- r.WritePrometheus(w) }()
+	<-w.writing
+
+	// a stalled scrape does not hold up recording
+	recorded := make(chan struct{})
+	go func() {
+		r.FetchAttempt("config", "", "pull", time.Millisecond)
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(time.Second):
+		t.Error("expected recording not to wait for the scrape")
+	}
+
+	close(w.release)
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryServedBy(t *testing.T) {
+	r := NewRegistry()
+	var _ EndpointMetrics = r
+
+	r.ServedBy("config", "", "https://sailor-a.example")
+	r.ServedBy("config", "", "https://sailor-b.example")
+
+	snapshots := r.Snapshot()
+	if len(snapshots) != 1 || snapshots[0].Endpoint != "https://sailor-b.example" {
+		t.Errorf("expected the last endpoint to be reported, got %+v", snapshots)
+	}
+}
+
+func TestRegistryCircuit(t *testing.T) {
+	r := NewRegistry()
+	var _ CircuitMetrics = r
+
+	var buf bytes.Buffer
+	r.WritePrometheus(&buf)
+	if strings.Contains(buf.String(), "sailor_circuit_state") {
+		t.Errorf("expected no circuit metrics without a circuit breaker")
+	}
+
+	r.CircuitState("closed", time.Unix(1700000000, 0))
+	r.CircuitState("open", time.Unix(1700000060, 0))
+
+	if snap := r.Circuit(); snap.State != "open" || snap.Opened != 1 || snap.Since.Unix() != 1700000060 {
+		t.Errorf("unexpected circuit snapshot %+v", snap)
+	}
+
+	buf.Reset()
+	r.WritePrometheus(&buf)
+	for _, want := range []string{
+		`sailor_circuit_state{state="closed"} 0`,
+		`sailor_circuit_state{state="open"} 1`,
+		`sailor_circuit_opened_total 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected output to contain %s\n%s", want, buf.String())
+		}
+	}
+}
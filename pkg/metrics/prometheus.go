@@ -0,0 +1,210 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Handler serves the registry in the Prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WritePrometheus(w); err != nil {
+			slog.Debug("sailor metrics scrape not written", slog.String("remote", req.RemoteAddr), slog.Any("error", err))
+		}
+	})
+}
+
+// WritePrometheus writes every metric of the registry in the Prometheus text
+// exposition format. The metrics are copied first, a slow writer does not hold
+// up the fetches recording metrics meanwhile.
+func (r *Registry) WritePrometheus(out io.Writer) error {
+	r = r.clone()
+	w := bufio.NewWriter(out)
+
+	writeHeader(w, "sailor_fetch_attempts_total", "counter", "Attempts to fetch a resource from a source.")
+	for _, k := range sortedFetchKeys(r.attempts) {
+		fmt.Fprintf(w, "sailor_fetch_attempts_total%s %d\n", fetchLabels(k), r.attempts[k])
+	}
+
+	writeHeader(w, "sailor_fetch_duration_seconds", "histogram", "Time taken to fetch a resource from a source.")
+	for _, k := range sortedFetchKeys(r.latency) {
+		h := r.latency[k]
+		for i, b := range latencyBuckets {
+			fmt.Fprintf(w, "sailor_fetch_duration_seconds_bucket%s %d\n",
+				labels(k.Kind, k.Name, "source", k.Source, "le", strconv.FormatFloat(b, 'g', -1, 64)), h.counts[i])
+		}
+		fmt.Fprintf(w, "sailor_fetch_duration_seconds_bucket%s %d\n",
+			labels(k.Kind, k.Name, "source", k.Source, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "sailor_fetch_duration_seconds_sum%s %g\n", fetchLabels(k), h.sum)
+		fmt.Fprintf(w, "sailor_fetch_duration_seconds_count%s %d\n", fetchLabels(k), h.count)
+	}
+
+	writeHeader(w, "sailor_http_responses_total", "counter", "HTTP responses received per status code.")
+	statusKeys := make([]statusKey, 0, len(r.statuses))
+	for k := range r.statuses {
+		statusKeys = append(statusKeys, k)
+	}
+	sort.Slice(statusKeys, func(i, j int) bool {
+		if statusKeys[i].resourceKey != statusKeys[j].resourceKey {
+			return lessResource(statusKeys[i].resourceKey, statusKeys[j].resourceKey)
+		}
+		return statusKeys[i].Code < statusKeys[j].Code
+	})
+	for _, k := range statusKeys {
+		fmt.Fprintf(w, "sailor_http_responses_total%s %d\n",
+			labels(k.Kind, k.Name, "code", strconv.Itoa(k.Code)), r.statuses[k])
+	}
+
+	writeCounter(w, "sailor_fallback_activations_total", "Times the fallback source was used.", r.fallbacks)
+	writeCounter(w, "sailor_decode_failures_total", "Resources which could not be decoded or decrypted.", r.failures)
+	writeCounter(w, "sailor_reloads_total", "Resources successfully stored.", r.reloads)
+
+	writeHeader(w, "sailor_last_success_timestamp_seconds", "gauge", "Unix time of the last successful reload.")
+	for _, k := range sortedResourceKeys(r.lastSuccess) {
+		fmt.Fprintf(w, "sailor_last_success_timestamp_seconds%s %d\n", labels(k.Kind, k.Name), r.lastSuccess[k].Unix())
+	}
+
+	writeHeader(w, "sailor_resource_version_info", "gauge", "Version of the resource currently in use.")
+	for _, k := range sortedResourceKeys(r.versions) {
+		fmt.Fprintf(w, "sailor_resource_version_info%s 1\n", labels(k.Kind, k.Name, "version", r.versions[k]))
+	}
+
+	writeHeader(w, "sailor_resource_endpoint_info", "gauge", "Sailor endpoint the resource was last fetched from.")
+	for _, k := range sortedResourceKeys(r.endpoints) {
+		fmt.Fprintf(w, "sailor_resource_endpoint_info%s 1\n", labels(k.Kind, k.Name, "endpoint", r.endpoints[k]))
+	}
+
+	if r.circuitState != "" {
+		writeHeader(w, "sailor_circuit_state", "gauge", "State of the circuit breaker around the Sailor API, 1 for the current one.")
+		for _, state := range []string{"closed", "half-open", "open"} {
+			current := 0
+			if state == r.circuitState {
+				current = 1
+			}
+			fmt.Fprintf(w, "sailor_circuit_state{state=\"%s\"} %d\n", state, current)
+		}
+		writeHeader(w, "sailor_circuit_opened_total", "counter", "Times the circuit breaker opened.")
+		fmt.Fprintf(w, "sailor_circuit_opened_total %d\n", r.circuitOpened)
+	}
+
+	return w.Flush()
+}
+
+// clone copies the metrics recorded so far
+func (r *Registry) clone() *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	latency := make(map[fetchKey]*histogram, len(r.latency))
+	for k, h := range r.latency {
+		latency[k] = &histogram{counts: slices.Clone(h.counts), count: h.count, sum: h.sum}
+	}
+	return &Registry{
+		attempts:      maps.Clone(r.attempts),
+		latency:       latency,
+		statuses:      maps.Clone(r.statuses),
+		fallbacks:     maps.Clone(r.fallbacks),
+		failures:      maps.Clone(r.failures),
+		reloads:       maps.Clone(r.reloads),
+		lastSuccess:   maps.Clone(r.lastSuccess),
+		versions:      maps.Clone(r.versions),
+		endpoints:     maps.Clone(r.endpoints),
+		circuitState:  r.circuitState,
+		circuitSince:  r.circuitSince,
+		circuitOpened: r.circuitOpened,
+	}
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeCounter(w io.Writer, name, help string, values map[resourceKey]uint64) {
+	writeHeader(w, name, "counter", help)
+	for _, k := range sortedResourceKeys(values) {
+		fmt.Fprintf(w, "%s%s %d\n", name, labels(k.Kind, k.Name), values[k])
+	}
+}
+
+// labels renders the kind and name labels followed by extra key/value pairs
+func labels(kind, name string, extra ...string) string {
+	var b strings.Builder
+	b.WriteString(`{kind="`)
+	b.WriteString(escapeLabel(kind))
+	b.WriteString(`",name="`)
+	b.WriteString(escapeLabel(name))
+	b.WriteString(`"`)
+	for i := 0; i+1 < len(extra); i += 2 {
+		b.WriteString(",")
+		b.WriteString(extra[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(extra[i+1]))
+		b.WriteString(`"`)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func fetchLabels(k fetchKey) string {
+	return labels(k.Kind, k.Name, "source", k.Source)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func lessResource(a, b resourceKey) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	return a.Name < b.Name
+}
+
+func sortedResourceKeys[V any](m map[resourceKey]V) []resourceKey {
+	keys := make([]resourceKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessResource(keys[i], keys[j]) })
+	return keys
+}
+
+func sortedFetchKeys[V any](m map[fetchKey]V) []fetchKey {
+	keys := make([]fetchKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].resourceKey != keys[j].resourceKey {
+			return lessResource(keys[i].resourceKey, keys[j].resourceKey)
+		}
+		return keys[i].Source < keys[j].Source
+	})
+	return keys
+}
@@ -15,7 +15,15 @@
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package opts
 
-import "time"
+import (
+	"crypto/ed25519"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+)
 
 type ResourceKind string
 
@@ -26,26 +34,128 @@ const (
 	PULL
 	DEV
 
+	// STREAM pulls the resource once and then applies changes as soon as Sailor
+	// announces them on its watch endpoint, falling back to pulling every
+	// PullInterval when the server does not support streaming
+	STREAM
+
 	CONFIGS ResourceKind = "config"
 	SECRETS ResourceKind = "secret"
 	MISC    ResourceKind = "misc"
 )
 
+// SecretEncoding tells the consumer how the raw secret resource is laid out
+type SecretEncoding int
+
+const (
+	// VAULT secrets are a map of vault.SecretRecord values encrypted with the
+	// KEK derived from AccessKey/SecretKey, this is the default
+	VAULT SecretEncoding = iota
+
+	// PLAINTEXT secrets are a plain JSON object bound directly into S, no Sailor
+	// credentials are needed to read them
+	PLAINTEXT
+
+	// BASE64 secrets are a JSON object of base64 encoded values, the same shape
+	// as the data section of a Kubernetes Secret
+	BASE64
+)
+
 type ConnectionOption struct {
-	URI           string
-	Addr          string
-	Namespace     string
-	App           string
-	AccessKey     string
-	SecretKey     string
-	Token         string
+	URI  string
+	Addr string
+
+	// Addrs are more Sailor replicas serving the same data as Addr, requests
+	// fail over across all of them when one cannot be reached
+	Addrs []string
+
+	Namespace string
+	App       string
+	AccessKey string
+	SecretKey string
+	Token     string
+
+	// Env picks the env of the ~/.sailor/config manifest to connect to when
+	// UseSailorConfig is set, instead of the active one. SAILOR_ENV overrides it.
 	Env           string
 	SocketTimeout time.Duration
+
+	// FallbackBaseURL is where fallback resources are fetched from,
+	// SAILOR_FALLBACK_BASE_URL overrides it
+	FallbackBaseURL string
+
+	// CAFile is a PEM bundle of the certificate authorities trusted when talking
+	// to Sailor over TLS, the system pool is used when empty
+	CAFile string
+
+	// PullInterval is used by the resources which do not set their own
+	// FetchDefinition.PullInterval
+	PullInterval time.Duration
+}
+
+// redacted replaces credentials in ConnectionOption.String
+const redacted = "REDACTED"
+
+// String renders the connection as a Sailor URI with the secret key redacted,
+// parsing it back gives the same connection apart from the credentials
+func (c ConnectionOption) String() string {
+	u := url.URL{Scheme: "sailor", Host: c.Addr}
+	if scheme, host, ok := strings.Cut(c.Addr, "://"); ok {
+		u.Scheme, u.Host = "sailor+"+scheme, host
+	}
+	for _, addr := range c.Addrs {
+		_, host, ok := strings.Cut(addr, "://")
+		if !ok {
+			host = addr
+		}
+		u.Host += "," + host
+	}
+
+	if c.AccessKey != "" {
+		u.User = url.UserPassword(c.AccessKey, redacted)
+	}
+	u.Path = "/" + c.Namespace + "/" + c.App
+
+	query := url.Values{}
+	if c.SocketTimeout != 0 {
+		query.Set("timeout", c.SocketTimeout.String())
+	}
+	if c.PullInterval != 0 {
+		query.Set("interval", c.PullInterval.String())
+	}
+	if c.Env != "" {
+		query.Set("env", c.Env)
+	}
+	if c.FallbackBaseURL != "" {
+		fallback := c.FallbackBaseURL
+		if fu, err := url.Parse(fallback); err == nil && fu.User != nil {
+			fu.User = nil
+			fallback = fu.String()
+		}
+		query.Set("fallback", fallback)
+	}
+	if c.CAFile != "" {
+		query.Set("ca", c.CAFile)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
 }
 
 type InitOption struct {
 	Connection *ConnectionOption
-	Logging    bool
+
+	// Logging toggles the structured events emitted by the Sailor Client, events
+	// are logged unless it is set to false
+	Logging *bool
+
+	// Logger receives the structured events unless Logging is off and defaults
+	// to slog.Default()
+	Logger *slog.Logger
+
+	// Metrics receives fetch, fallback, decode and reload measurements for every
+	// resource and defaults to a fresh metrics.Registry
+	Metrics metrics.Metrics
 
 	// Resources defines what all resources does the Sailor Client need to manage
 	Resources []ResourceOption
@@ -56,12 +166,94 @@ type InitOption struct {
 	// UseSailorConfig reads connection details (host, token, env) from ~/.sailor/config.
 	// Connection.Namespace and Connection.App must still be provided by the caller.
 	UseSailorConfig bool
+
+	// ReloadOnSIGHUP refreshes every resource when the process receives SIGHUP
+	ReloadOnSIGHUP bool
+
+	// AsyncStart makes Start return at once and load the resources in the
+	// background, use WaitReady to know when the required ones are loaded
+	AsyncStart bool
+
+	// FirstReadTimeout makes Get, GetSecret and GetMisc wait up to this long for
+	// the resource to be loaded the first time instead of failing right away,
+	// zero disables waiting
+	FirstReadTimeout time.Duration
+
+	// CircuitBreaker stops calling Sailor for a while once it keeps failing,
+	// resources are served from fallback or the dev cache meanwhile. Nil
+	// disables it.
+	CircuitBreaker *CircuitBreakerOption
+
+	// HistorySize is how many decoded versions of each resource are kept for
+	// History and Rollback, defaults to 5. A negative value disables history.
+	HistorySize int
+
+	// Report tells Sailor which version of every resource the consumer applied,
+	// on every change and periodically. Nil disables reporting.
+	Report *ReportOption
+
+	// Signature rejects pulled and fallback payloads which are not signed by a
+	// trusted ed25519 key. Nil disables verification.
+	Signature *SignatureOption
+
+	// EncryptedFallback makes fallback files and the DEV cache hold payloads
+	// sealed with the app AccessKey and SecretKey by the envelope package, for
+	// every kind. Fallback files which are not sealed are rejected.
+	EncryptedFallback bool
+}
+
+// SignatureOption lists the keys payloads must be signed with. Pulled payloads
+// carry their signature in the X-Sailor-Payload-Signature header, fallback files
+// in that header or in a detached file next to them with a .sig suffix.
+type SignatureOption struct {
+	// PublicKeys are the trusted keys, a payload signed by any of them is
+	// accepted. List both the old and the new key while rotating.
+	PublicKeys []ed25519.PublicKey
+}
+
+// ReportOption tunes the reports of applied versions sent to Sailor
+type ReportOption struct {
+	// InstanceID tells this consumer apart from the other replicas of the app,
+	// defaults to the hostname which is the pod name on Kubernetes
+	InstanceID string
+
+	// Interval is how often a report is sent even when nothing changed,
+	// defaults to 1 minute
+	Interval time.Duration
+
+	// BatchWindow is how long to wait after a change for more changes to send
+	// them in a single report, defaults to 1 second
+	BatchWindow time.Duration
+}
+
+// CircuitBreakerOption tunes when the circuit around the Sailor API opens and
+// how it is probed again
+type CircuitBreakerOption struct {
+	// FailureThreshold consecutive failed requests open the circuit, a request
+	// fails when Sailor cannot be reached or answers with a 5xx. Defaults to 5.
+	FailureThreshold int
+
+	// OpenTimeout is how long requests fail right away once the circuit opened
+	// before Sailor is probed again. Defaults to 30 seconds.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is how many probe requests are let through at once when
+	// OpenTimeout is over, the circuit closes when they succeed. Defaults to 1.
+	HalfOpenRequests int
 }
 
 type ResourceDefinition struct {
 	Kind ResourceKind
 	Name string
 	Path string
+
+	// SecretEncoding is only used for SECRETS and defaults to VAULT
+	SecretEncoding SecretEncoding
+
+	// Version pins the resource to a version served by Sailor, like "v42",
+	// instead of the latest one. It is honored by PULL, STREAM, DEV and the bulk
+	// fetch, background pulls then only check that the version is still served.
+	Version string
 }
 
 type FetchDefinition struct {
@@ -73,8 +265,9 @@ type FetchDefinition struct {
 	// And for Misc resource we will fetch only once from Sailor
 	Once bool
 
-	// PullInterval is only used for FetchOption.Pull and defaults to 10 seconds
-	// if not passed during Resource Definition
+	// PullInterval is only used for FetchOption.Pull (and FetchOption.Stream when
+	// streaming is unavailable) and defaults to 10 seconds if not passed during
+	// Resource Definition
 	PullInterval time.Duration
 }
 
@@ -82,6 +275,20 @@ type ResourceOption struct {
 	Def             ResourceDefinition
 	FetchDef        FetchDefinition
 	FallbackEnabled bool
+
+	// MaxStaleness marks the consumer as not ready when the resource was last
+	// successfully loaded longer ago than this, zero disables the check
+	MaxStaleness time.Duration
+
+	// Required makes Start fail when the resource cannot be loaded, this is the
+	// default. An optional resource which fails to load is retried in the
+	// background until it loads.
+	Required *bool
+}
+
+// IsRequired tells if Start must fail when the resource cannot be loaded
+func (r ResourceOption) IsRequired() bool {
+	return r.Required == nil || *r.Required
 }
 
 type SailorMeta struct {
@@ -93,3 +300,46 @@ type SailorState struct {
 	Config  []byte            `json:"config"`
 	Secrets map[string][]byte `json:"secrets"`
 }
+
+// SailorBulkState is the envelope served by the bulk endpoint, carrying every
+// requested resource of an app in a single response. A resource missing from
+// the envelope is fetched on its own.
+type SailorBulkState struct {
+	Config  *SailorBulkResource           `json:"config,omitempty"`
+	Secrets *SailorBulkResource           `json:"secrets,omitempty"`
+	Misc    map[string]SailorBulkResource `json:"misc,omitempty"`
+}
+
+// SailorBulkResource is a single resource inside SailorBulkState, Data holds the
+// exact bytes the per-resource endpoint would have served
+type SailorBulkResource struct {
+	Version string `json:"version"`
+	Data    []byte `json:"data"`
+
+	// Signature is the base64 ed25519 signature of Data, the per-resource
+	// endpoint would have sent it in the X-Sailor-Payload-Signature header
+	Signature string `json:"signature,omitempty"`
+}
+
+// SailorReport is sent to the report endpoint with the versions of the
+// resources a consumer instance applied
+type SailorReport struct {
+	Namespace  string                 `json:"namespace"`
+	App        string                 `json:"app"`
+	InstanceID string                 `json:"instance_id"`
+	At         time.Time              `json:"at"`
+	Resources  []SailorReportResource `json:"resources"`
+}
+
+// SailorReportResource is the state of a single resource inside SailorReport
+type SailorReportResource struct {
+	Kind      ResourceKind `json:"kind"`
+	Name      string       `json:"name,omitempty"`
+	Loaded    bool         `json:"loaded"`
+	Version   string       `json:"version,omitempty"`
+	Source    string       `json:"source,omitempty"`
+	AppliedAt time.Time    `json:"applied_at,omitzero"`
+
+	// Pinned is true while a rollback pinned the version in use
+	Pinned bool `json:"pinned,omitempty"`
+}
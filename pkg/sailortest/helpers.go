@@ -0,0 +1,163 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailortest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/envelope"
+	"github.com/sailorhq/sailor/pkg/vault"
+)
+
+// pollInterval is how often the Wait helpers check the consumer
+const pollInterval = 5 * time.Millisecond
+
+// ConfigGetter is satisfied by *sailor.Consumer and anything providing configs
+type ConfigGetter[C any] interface {
+	Get() (C, error)
+}
+
+// SecretGetter is satisfied by *sailor.Consumer and anything providing secrets
+type SecretGetter[S any] interface {
+	GetSecret() (S, error)
+}
+
+// MiscGetter is satisfied by *sailor.Consumer and anything providing misc resources
+type MiscGetter interface {
+	GetMisc(name string) ([]byte, error)
+}
+
+// EncryptSecrets encrypts the values with the same KEK/DEK envelope Sailor uses,
+// the KEK being derived from the secret key and access key of the app
+func EncryptSecrets(accessKey, secretKey string, values map[string]string) (map[string]vault.SecretRecord, error) {
+	kek, err := vault.DeriveKEK(secretKey, []byte(accessKey))
+	if err != nil {
+		return nil, err
+	}
+
+	encSecrets := make(map[string]vault.SecretRecord, len(values))
+	for k, v := range values {
+		dek, err := vault.GenerateDEK()
+		if err != nil {
+			return nil, err
+		}
+
+		encSecret, _, err := vault.EncryptWithDEK(v, dek)
+		if err != nil {
+			return nil, err
+		}
+
+		encDek, err := vault.EncryptDEK(dek, kek)
+		if err != nil {
+			return nil, err
+		}
+
+		encSecrets[k] = vault.SecretRecord{
+			EncryptedDEK:    encDek,
+			EncryptedSecret: encSecret,
+		}
+	}
+
+	return encSecrets, nil
+}
+
+// EncryptFallback seals v the way EncryptedFallback consumers expect fallback
+// files to be, v is marshaled to JSON unless it is a []byte already. Pass the
+// result to SetFallback.
+func EncryptFallback(accessKey, secretKey string, v any) ([]byte, error) {
+	payload, ok := v.([]byte)
+	if !ok {
+		var err error
+		if payload, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return envelope.Seal(accessKey, secretKey, payload)
+}
+
+// Eventually fails the test if cond does not become true within timeout
+func Eventually(t testing.TB, timeout time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+			return
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// WaitForConfig waits for the consumer to observe a config matching pred and
+// returns it, failing the test after timeout
+func WaitForConfig[C any](t testing.TB, g ConfigGetter[C], timeout time.Duration, pred func(C) bool) C {
+	t.Helper()
+
+	var last C
+	Eventually(t, timeout, func() bool {
+		config, err := g.Get()
+		if err != nil {
+			return false
+		}
+		last = config
+		return pred(config)
+	})
+
+	return last
+}
+
+// WaitForSecret waits for the consumer to observe secrets matching pred and
+// returns them, failing the test after timeout
+func WaitForSecret[S any](t testing.TB, g SecretGetter[S], timeout time.Duration, pred func(S) bool) S {
+	t.Helper()
+
+	var last S
+	Eventually(t, timeout, func() bool {
+		secrets, err := g.GetSecret()
+		if err != nil {
+			return false
+		}
+		last = secrets
+		return pred(secrets)
+	})
+
+	return last
+}
+
+// WaitForMisc waits for the consumer to observe the misc resource matching pred
+// and returns it, failing the test after timeout
+func WaitForMisc(t testing.TB, g MiscGetter, name string, timeout time.Duration, pred func([]byte) bool) []byte {
+	t.Helper()
+
+	var last []byte
+	Eventually(t, timeout, func() bool {
+		b, err := g.GetMisc(name)
+		if err != nil {
+			return false
+		}
+		last = b
+		return pred(b)
+	})
+
+	return last
+}
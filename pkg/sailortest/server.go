@@ -0,0 +1,639 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package sailortest provides an in-process fake Sailor server and helpers for
+// testing applications which consume resources through sailor-go.
+package sailortest
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/signing"
+)
+
+// HeaderVersion is the response header through which Sailor announces the
+// version of the resource being served
+const HeaderVersion = "x-sailor-version"
+
+// fallbackPrefix is the path under which fallback files are served
+const fallbackPrefix = "/fallback"
+
+type resourceKey struct {
+	ns   string
+	app  string
+	kind opts.ResourceKind
+	name string
+}
+
+type resource struct {
+	body    []byte
+	version int
+
+	// history is the body of every version still served when asked for by
+	// version
+	history map[int][]byte
+}
+
+// at returns the body of a version given as "v42"
+func (r *resource) at(version string) ([]byte, int, bool) {
+	var n int
+	if _, err := fmt.Sscanf(version, "v%d", &n); err != nil {
+		return nil, 0, false
+	}
+	body, ok := r.history[n]
+	return body, n, ok
+}
+
+// Server is a fake Sailor server serving config, secret and misc resources for
+// any number of namespaces and apps along with fallback files
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	resources map[resourceKey]*resource
+	fallbacks map[string][]byte
+	status    int
+	latency   time.Duration
+	requests  map[string]int
+
+	// watchers get the change events of their app, streamingOff makes the
+	// watch endpoint respond with 404
+	watchers     map[appKey][]chan watchEvent
+	streamingOff bool
+	closed       chan struct{}
+
+	// bulkOff makes the bulk endpoint respond with 404
+	bulkOff bool
+
+	// verifier rejects unsigned API requests when set, see RequireSigning
+	verifier *signing.Verifier
+
+	// reports are the applied version reports received per app
+	reports map[appKey][]opts.SailorReport
+
+	// payloadKey signs the served payloads when set, see SignPayloads
+	payloadKey ed25519.PrivateKey
+}
+
+type appKey struct {
+	ns  string
+	app string
+}
+
+// watchEvent is announced on the watch endpoint when a resource changes
+type watchEvent struct {
+	Kind    opts.ResourceKind `json:"kind"`
+	Name    string            `json:"name,omitempty"`
+	Version string            `json:"version"`
+}
+
+// NewServer starts a fake Sailor server which is closed when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		resources: map[resourceKey]*resource{},
+		fallbacks: map[string][]byte{},
+		requests:  map[string]int{},
+		watchers:  map[appKey][]chan watchEvent{},
+		closed:    make(chan struct{}),
+		reports:   map[appKey][]opts.SailorReport{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(func() {
+		// open watch streams would otherwise keep Close waiting
+		close(s.closed)
+		s.Close()
+	})
+
+	return s
+}
+
+// FallbackURL is the value to set SAILOR_FALLBACK_BASE_URL to for the consumer
+// to fetch fallback files from this server
+func (s *Server) FallbackURL() string {
+	return s.URL + fallbackPrefix
+}
+
+// Connection returns a ConnectionOption pointing to this server
+func (s *Server) Connection(ns, app, accessKey, secretKey string) *opts.ConnectionOption {
+	return &opts.ConnectionOption{
+		Addr:      s.URL,
+		Namespace: ns,
+		App:       app,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	}
+}
+
+// SetConfig serves v encoded as JSON as the config of the app, every call bumps
+// the version of the resource
+func (s *Server) SetConfig(ns, app string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.SetRaw(ns, app, opts.CONFIGS, "", b)
+	return nil
+}
+
+// SetSecrets serves the values encrypted with the vault format for the given
+// access and secret key as the secret of the app
+func (s *Server) SetSecrets(ns, app, accessKey, secretKey string, values map[string]string) error {
+	encSecrets, err := EncryptSecrets(accessKey, secretKey, values)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(encSecrets)
+	if err != nil {
+		return err
+	}
+
+	s.SetRaw(ns, app, opts.SECRETS, "", b)
+	return nil
+}
+
+// SetMisc serves b as the misc resource with the given name
+func (s *Server) SetMisc(ns, app, name string, b []byte) {
+	s.SetRaw(ns, app, opts.MISC, name, b)
+}
+
+// SetRaw serves b as-is for the resource, name is only used for misc resources
+func (s *Server) SetRaw(ns, app string, kind opts.ResourceKind, name string, b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := resourceKey{ns, app, kind, name}
+	res, ok := s.resources[key]
+	if !ok {
+		res = &resource{}
+		s.resources[key] = res
+	}
+	res.body = b
+	res.version++
+	if res.history == nil {
+		res.history = map[int][]byte{}
+	}
+	res.history[res.version] = b
+
+	s.announce(ns, app, watchEvent{Kind: kind, Name: name, Version: fmt.Sprintf("v%d", res.version)})
+}
+
+// ForgetVersion stops serving an older version of the resource when asked for
+// by version, like a Sailor server which no longer keeps it. The latest version
+// is still served to requests not asking for a version.
+func (s *Server) ForgetVersion(ns, app string, kind opts.ResourceKind, name string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if res, ok := s.resources[resourceKey{ns, app, kind, name}]; ok {
+		delete(res.history, version)
+	}
+}
+
+// SetStreaming turns the watch endpoint on or off, it is on by default. When
+// off the server responds with 404 like a Sailor server without streaming.
+func (s *Server) SetStreaming(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streamingOff = !on
+}
+
+// RequireSigning makes the server answer 401 to resource, bulk and watch
+// requests which are not signed with one of the access key to secret key pairs,
+// nil keys turns the check off. Fallback files are not affected.
+func (s *Server) RequireSigning(keys map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if keys == nil {
+		s.verifier = nil
+		return
+	}
+	s.verifier = &signing.Verifier{SecretKey: signing.StaticKeys(maps.Clone(keys))}
+}
+
+// SignPayloads signs every served resource with key: in the
+// X-Sailor-Payload-Signature header, in the bulk envelope and as a detached .sig
+// file next to each fallback file. Nil stops signing.
+func (s *Server) SignPayloads(key ed25519.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloadKey = key
+}
+
+// sign is the signature of the payload, empty when not signing, the caller
+// must hold mu
+func (s *Server) sign(payload []byte) string {
+	if s.payloadKey == nil {
+		return ""
+	}
+	return signing.SignPayload(s.payloadKey, payload)
+}
+
+// SetBulk turns the bulk endpoint on or off, it is on by default. When off the
+// server responds with 404 like a Sailor server without bulk fetching.
+func (s *Server) SetBulk(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bulkOff = !on
+}
+
+// announce sends the event to every watcher of the app, the caller must hold mu
+func (s *Server) announce(ns, app string, ev watchEvent) {
+	for _, ch := range s.watchers[appKey{ns, app}] {
+		select {
+		case ch <- ev:
+		default:
+			// a slow watcher misses the event and catches up on reconnect
+		}
+	}
+}
+
+// Delete stops serving the resource, the server responds with 404 for it
+func (s *Server) Delete(ns, app string, kind opts.ResourceKind, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resources, resourceKey{ns, app, kind, name})
+}
+
+// SetFallback serves b as the fallback file of kind for the app
+func (s *Server) SetFallback(app string, kind opts.ResourceKind, b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallbacks[fallbackFileName(app, kind)] = b
+}
+
+// SetStatus makes every resource request fail with the given status code, zero
+// restores normal serving. Fallback files are not affected.
+func (s *Server) SetStatus(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+// SetLatency delays every response of the server by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns how many requests the server received for the path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// Version returns the current version of the resource, zero if not served
+func (s *Server) Version(ns, app string, kind opts.ResourceKind, name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if res, ok := s.resources[resourceKey{ns, app, kind, name}]; ok {
+		return res.version
+	}
+	return 0
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	latency, status := s.latency, s.status
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if file, ok := strings.CutPrefix(r.URL.Path, fallbackPrefix+"/"); ok {
+		s.mu.Lock()
+		signed, isSig := strings.CutSuffix(file, signing.PayloadSignatureSuffix)
+		b, ok := s.fallbacks[signed]
+		if isSig {
+			b = []byte(s.sign(b))
+			ok = ok && len(b) > 0
+		}
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(b)
+		return
+	}
+
+	s.mu.Lock()
+	verifier := s.verifier
+	s.mu.Unlock()
+	if verifier != nil {
+		if _, err := verifier.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if wk, ok := parseWatchPath(r.URL.Path); ok {
+		s.serveWatch(w, r, wk, status)
+		return
+	}
+
+	if wk, ok := parseBulkPath(r.URL.Path); ok {
+		s.serveBulk(w, r, wk, status)
+		return
+	}
+
+	if wk, ok := parseReportPath(r.URL.Path); ok {
+		s.serveReport(w, r, wk, status)
+		return
+	}
+
+	key, ok := parseResourcePath(r.URL.Path)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	s.mu.Lock()
+	res, ok := s.resources[key]
+	var body []byte
+	var version int
+	served := ok
+	if ok {
+		body, version = res.body, res.version
+		if requested := r.URL.Query().Get("version"); requested != "" {
+			body, version, served = res.at(requested)
+		}
+	}
+	sig := s.sign(body)
+	s.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !served {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	w.Header().Set(HeaderVersion, fmt.Sprintf("v%d", version))
+	if sig != "" {
+		w.Header().Set(signing.HeaderPayloadSignature, sig)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// serveWatch streams the changes of the app resources as server-sent events.
+// The resources listed with the version the watcher uses, as config@v1 or
+// misc/certs@v2, are announced right away when a newer version is served.
+func (s *Server) serveWatch(w http.ResponseWriter, r *http.Request, wk appKey, status int) {
+	s.mu.Lock()
+	off := s.streamingOff
+	s.mu.Unlock()
+
+	if off {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	ch := make(chan watchEvent, 16)
+	s.mu.Lock()
+	s.watchers[wk] = append(s.watchers[wk], ch)
+	for _, known := range r.URL.Query()["resource"] {
+		known, version, _ := strings.Cut(known, "@")
+		kind, name, _ := strings.Cut(known, "/")
+		res, ok := s.resources[resourceKey{wk.ns, wk.app, opts.ResourceKind(kind), name}]
+		if !ok {
+			continue
+		}
+		if current := fmt.Sprintf("v%d", res.version); current != version {
+			select {
+			case ch <- watchEvent{Kind: opts.ResourceKind(kind), Name: name, Version: current}:
+			default:
+			}
+		}
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.watchers[wk] = slices.DeleteFunc(s.watchers[wk], func(c chan watchEvent) bool { return c == ch })
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			b, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "event: change\ndata: %s\n\n", b)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// serveBulk serves the requested resources of the app which exist in a single
+// envelope, resources are requested as config, secret or misc/{name}
+func (s *Server) serveBulk(w http.ResponseWriter, r *http.Request, wk appKey, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bulkOff {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	state := opts.SailorBulkState{}
+	for _, requested := range r.URL.Query()["resource"] {
+		requested, pinned, _ := strings.Cut(requested, "@")
+		kind, name, _ := strings.Cut(requested, "/")
+		res, ok := s.resources[resourceKey{wk.ns, wk.app, opts.ResourceKind(kind), name}]
+		if !ok {
+			continue
+		}
+
+		body, version := res.body, res.version
+		if pinned != "" {
+			if body, version, ok = res.at(pinned); !ok {
+				continue
+			}
+		}
+
+		bulkRes := opts.SailorBulkResource{Version: fmt.Sprintf("v%d", version), Data: body, Signature: s.sign(body)}
+		switch opts.ResourceKind(kind) {
+		case opts.CONFIGS:
+			state.Config = &bulkRes
+		case opts.SECRETS:
+			state.Secrets = &bulkRes
+		case opts.MISC:
+			if state.Misc == nil {
+				state.Misc = map[string]opts.SailorBulkResource{}
+			}
+			state.Misc[name] = bulkRes
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// serveReport keeps the applied version reports sent by consumers
+func (s *Server) serveReport(w http.ResponseWriter, r *http.Request, wk appKey, status int) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	var report opts.SailorReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.reports[wk] = append(s.reports[wk], report)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reports returns the applied version reports received for the app, oldest
+// first
+func (s *Server) Reports(ns, app string) []opts.SailorReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.reports[appKey{ns, app}])
+}
+
+// Watchers returns how many watch streams are open for the app
+func (s *Server) Watchers(ns, app string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.watchers[appKey{ns, app}])
+}
+
+// CloseWatchers drops every open watch stream, like a restart of Sailor would
+func (s *Server) CloseWatchers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for wk, chans := range s.watchers {
+		for _, ch := range chans {
+			close(ch)
+		}
+		delete(s.watchers, wk)
+	}
+}
+
+// parseWatchPath understands /api/v1/watch/{ns}/{app}
+func parseWatchPath(path string) (appKey, bool) {
+	return parseAppPath(path, "/api/v1/watch/")
+}
+
+// parseBulkPath understands /api/v1/bulk/{ns}/{app}
+func parseBulkPath(path string) (appKey, bool) {
+	return parseAppPath(path, "/api/v1/bulk/")
+}
+
+// parseReportPath understands /api/v1/report/{ns}/{app}
+func parseReportPath(path string) (appKey, bool) {
+	return parseAppPath(path, "/api/v1/report/")
+}
+
+func parseAppPath(path, prefix string) (appKey, bool) {
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok {
+		return appKey{}, false
+	}
+
+	ns, app, ok := strings.Cut(rest, "/")
+	if !ok || ns == "" || app == "" || strings.Contains(app, "/") {
+		return appKey{}, false
+	}
+	return appKey{ns, app}, true
+}
+
+// parseResourcePath understands /api/v1/resource/{ns}/{app}/config|secret and
+// /api/v1/resource/{ns}/{app}/misc/{name}
+func parseResourcePath(path string) (resourceKey, bool) {
+	rest, ok := strings.CutPrefix(path, "/api/v1/resource/")
+	if !ok {
+		return resourceKey{}, false
+	}
+
+	parts := strings.Split(rest, "/")
+	switch {
+	case len(parts) == 3 && (parts[2] == string(opts.CONFIGS) || parts[2] == string(opts.SECRETS)):
+		return resourceKey{parts[0], parts[1], opts.ResourceKind(parts[2]), ""}, true
+	case len(parts) == 4 && parts[2] == string(opts.MISC):
+		return resourceKey{parts[0], parts[1], opts.MISC, parts[3]}, true
+	}
+
+	return resourceKey{}, false
+}
+
+func fallbackFileName(app string, kind opts.ResourceKind) string {
+	return fmt.Sprintf("%s-%s.sailor.fall", app, kind)
+}
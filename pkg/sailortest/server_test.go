@@ -0,0 +1,246 @@
+package sailortest
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/signing"
+)
+
+func get(t *testing.T, url string) (int, string, http.Header) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(b), resp.Header
+}
+
+func TestServerServesResources(t *testing.T) {
+	s := NewServer(t)
+	if err := s.SetConfig("ns", "app", map[string]string{"app": "one"}); err != nil {
+		t.Fatal(err)
+	}
+	s.SetMisc("ns", "other", "certs", []byte("pem"))
+
+	code, body, header := get(t, s.URL+"/api/v1/resource/ns/app/config")
+	if code != http.StatusOK || body != `{"app":"one"}` || header.Get(HeaderVersion) != "v1" {
+		t.Errorf("unexpected config response %d %s %v", code, body, header)
+	}
+
+	code, body, _ = get(t, s.URL+"/api/v1/resource/ns/other/misc/certs")
+	if code != http.StatusOK || body != "pem" {
+		t.Errorf("unexpected misc response %d %s", code, body)
+	}
+
+	if code, _, _ = get(t, s.URL+"/api/v1/resource/ns/other/config"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown resource got %d", code)
+	}
+
+	s.SetConfig("ns", "app", map[string]string{"app": "two"})
+	if v := s.Version("ns", "app", opts.CONFIGS, ""); v != 2 {
+		t.Errorf("expected version 2 got %d", v)
+	}
+
+	if n := s.Requests("/api/v1/resource/ns/app/config"); n != 1 {
+		t.Errorf("expected 1 request got %d", n)
+	}
+}
+
+func TestServerInjection(t *testing.T) {
+	s := NewServer(t)
+	s.SetConfig("ns", "app", map[string]string{"app": "one"})
+	s.SetFallback("app", opts.CONFIGS, []byte(`{"app":"fallback"}`))
+
+	s.SetStatus(http.StatusInternalServerError)
+	if code, _, _ := get(t, s.URL+"/api/v1/resource/ns/app/config"); code != http.StatusInternalServerError {
+		t.Errorf("expected injected status got %d", code)
+	}
+
+	code, body, _ := get(t, s.FallbackURL()+"/app-config.sailor.fall")
+	if code != http.StatusOK || body != `{"app":"fallback"}` {
+		t.Errorf("unexpected fallback response %d %s", code, body)
+	}
+
+	s.SetStatus(0)
+	s.SetLatency(30 * time.Millisecond)
+	started := time.Now()
+	get(t, s.URL+"/api/v1/resource/ns/app/config")
+	if time.Since(started) < 30*time.Millisecond {
+		t.Error("expected latency to be injected")
+	}
+}
+
+func TestServerWatch(t *testing.T) {
+	s := NewServer(t)
+
+	resp, err := http.Get(s.URL + "/api/v1/watch/ns/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+
+	Eventually(t, time.Second, func() bool { return s.Watchers("ns", "app") == 1 })
+	s.SetConfig("ns", "app", map[string]string{"app": "one"})
+	s.SetConfig("ns", "other", map[string]string{"app": "one"})
+	s.CloseWatchers()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ": connected\n\nevent: change\ndata: {\"kind\":\"config\",\"version\":\"v1\"}\n\n"
+	if string(body) != want {
+		t.Errorf("expected %q got %q", want, body)
+	}
+
+	// a watcher behind on a version hears about it right away
+	s.SetMisc("ns", "app", "certs", []byte("pem"))
+	resp, err = http.Get(s.URL + "/api/v1/watch/ns/app?resource=config@v1&resource=misc/certs@v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	Eventually(t, time.Second, func() bool { return s.Watchers("ns", "app") == 1 })
+	s.CloseWatchers()
+	if body, _ = io.ReadAll(resp.Body); !strings.Contains(string(body), `{"kind":"misc","name":"certs","version":"v1"}`) || strings.Contains(string(body), `"config"`) {
+		t.Errorf("expected only the stale misc to be announced got %q", body)
+	}
+
+	s.SetStreaming(false)
+	if code, _, _ := get(t, s.URL+"/api/v1/watch/ns/app"); code != http.StatusNotFound {
+		t.Errorf("expected 404 with streaming off got %d", code)
+	}
+}
+
+func TestServerBulk(t *testing.T) {
+	s := NewServer(t)
+	s.SetConfig("ns", "app", map[string]string{"app": "one"})
+	s.SetMisc("ns", "app", "certs", []byte("certs"))
+
+	code, body, _ := get(t, s.URL+"/api/v1/bulk/ns/app?resource=config&resource=secret&resource=misc/certs")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", code)
+	}
+
+	var state opts.SailorBulkState
+	if err := json.Unmarshal([]byte(body), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Config == nil || string(state.Config.Data) != `{"app":"one"}` || state.Config.Version != "v1" {
+		t.Errorf("unexpected config %+v", state.Config)
+	}
+	if state.Secrets != nil {
+		t.Errorf("expected no secrets got %+v", state.Secrets)
+	}
+	if string(state.Misc["certs"].Data) != "certs" {
+		t.Errorf("unexpected misc %+v", state.Misc)
+	}
+
+	s.SetBulk(false)
+	if code, _, _ := get(t, s.URL+"/api/v1/bulk/ns/app?resource=config"); code != http.StatusNotFound {
+		t.Errorf("expected 404 with bulk off got %d", code)
+	}
+}
+
+func TestServerVersions(t *testing.T) {
+	s := NewServer(t)
+	s.SetConfig("ns", "app", map[string]string{"app": "one"})
+	s.SetConfig("ns", "app", map[string]string{"app": "two"})
+
+	code, body, header := get(t, s.URL+"/api/v1/resource/ns/app/config?version=v1")
+	if code != http.StatusOK || body != `{"app":"one"}` || header.Get(HeaderVersion) != "v1" {
+		t.Errorf("unexpected pinned response %d %s %v", code, body, header)
+	}
+
+	code, body, _ = get(t, s.URL+"/api/v1/bulk/ns/app?resource=config@v1")
+	var state opts.SailorBulkState
+	json.Unmarshal([]byte(body), &state)
+	if code != http.StatusOK || state.Config == nil || state.Config.Version != "v1" {
+		t.Errorf("unexpected pinned bulk response %d %s", code, body)
+	}
+
+	s.ForgetVersion("ns", "app", opts.CONFIGS, "", 1)
+	if code, _, _ := get(t, s.URL+"/api/v1/resource/ns/app/config?version=v1"); code != http.StatusGone {
+		t.Errorf("expected 410 for a forgotten version got %d", code)
+	}
+	if code, body, _ := get(t, s.URL+"/api/v1/resource/ns/app/config"); code != http.StatusOK || body != `{"app":"two"}` {
+		t.Errorf("expected the latest version to be served got %d %s", code, body)
+	}
+}
+
+func TestServerReports(t *testing.T) {
+	s := NewServer(t)
+
+	body := `{"namespace":"ns","app":"app","instance_id":"pod-1","resources":[{"kind":"config","version":"v1"}]}`
+	resp, err := http.Post(s.URL+"/api/v1/report/ns/app", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected 204 got %d", resp.StatusCode)
+	}
+
+	reports := s.Reports("ns", "app")
+	if len(reports) != 1 || reports[0].InstanceID != "pod-1" || len(reports[0].Resources) != 1 || reports[0].Resources[0].Version != "v1" {
+		t.Errorf("unexpected reports %+v", reports)
+	}
+
+	if code, _, _ := get(t, s.URL+"/api/v1/report/ns/app"); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET got %d", code)
+	}
+}
+
+func TestServerSignsPayloads(t *testing.T) {
+	s := NewServer(t)
+	s.SetConfig("ns", "app", map[string]string{"app": "one"})
+	s.SetFallback("app", opts.CONFIGS, []byte(`{"app":"fallback"}`))
+
+	pub, key, _ := ed25519.GenerateKey(nil)
+	s.SignPayloads(key)
+	keys := []ed25519.PublicKey{pub}
+
+	_, body, header := get(t, s.URL+"/api/v1/resource/ns/app/config")
+	if !signing.VerifyPayload(keys, []byte(body), header.Get(signing.HeaderPayloadSignature)) {
+		t.Errorf("expected the resource to be signed")
+	}
+
+	_, body, _ = get(t, s.URL+"/api/v1/bulk/ns/app?resource=config")
+	var state opts.SailorBulkState
+	json.Unmarshal([]byte(body), &state)
+	if state.Config == nil || !signing.VerifyPayload(keys, state.Config.Data, state.Config.Signature) {
+		t.Errorf("expected the bulk resource to be signed got %s", body)
+	}
+
+	_, sig, _ := get(t, s.FallbackURL()+"/app-config.sailor.fall.sig")
+	if !signing.VerifyPayload(keys, []byte(`{"app":"fallback"}`), sig) {
+		t.Errorf("expected a detached fallback signature got %q", sig)
+	}
+
+	s.SignPayloads(nil)
+	if code, _, _ := get(t, s.FallbackURL()+"/app-config.sailor.fall.sig"); code != http.StatusNotFound {
+		t.Errorf("expected no detached signature when not signing got %d", code)
+	}
+	if _, _, header := get(t, s.URL+"/api/v1/resource/ns/app/config"); header.Get(signing.HeaderPayloadSignature) != "" {
+		t.Errorf("expected no signature header when not signing")
+	}
+}
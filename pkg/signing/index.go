@@ -0,0 +1,241 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package signing signs the requests the Sailor Client sends with the app
+// AccessKey and SecretKey and verifies them on the server side.
+//
+// A signed request carries the time it was signed in HeaderTimestamp, a value
+// unique to the request in HeaderNonce, the hex encoded SHA-256 of its body in
+// HeaderContentSHA256 and in the Authorization header:
+//
+//	Sailor-HMAC-SHA256 Credential=<access key>, Signature=<hex HMAC-SHA256>
+//
+// The signature is keyed with the SecretKey over the method, the path with its
+// query, the timestamp, the nonce and the body hash, each on its own line. The
+// same scheme signs the requests Sailor sends, like webhook notifications.
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Scheme and headers carrying the signature of a request
+	Scheme              = "Sailor-HMAC-SHA256"
+	HeaderTimestamp     = "X-Sailor-Timestamp"
+	HeaderNonce         = "X-Sailor-Nonce"
+	HeaderContentSHA256 = "X-Sailor-Content-Sha256"
+
+	// DefaultMaxSkew is how far the timestamp of a request may be from the
+	// clock of the verifier
+	DefaultMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrMalformed        = errors.New("request signature is malformed")
+	ErrUnknownKey       = errors.New("request is signed with an unknown access key")
+	ErrBadSignature     = errors.New("request signature does not match")
+	ErrBodyMismatch     = errors.New("request body does not match its signed hash")
+	ErrExpired          = errors.New("request timestamp is outside the allowed window")
+	ErrReplayed         = errors.New("request nonce was already used")
+)
+
+// Sign adds the signature headers to req for the given keys at time now. The
+// body, if any, is read through req.GetBody and left untouched. A nonce already
+// set in HeaderNonce is kept, otherwise a random one is generated.
+func Sign(req *http.Request, accessKey, secretKey string, now time.Time) error {
+	body, err := requestBody(req)
+	if err != nil {
+		return err
+	}
+
+	nonce := req.Header.Get(HeaderNonce)
+	if nonce == "" {
+		nonce = rand.Text()
+	}
+
+	bodyHash := hashBody(body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderContentSHA256, bodyHash)
+	req.Header.Set("Authorization", Scheme+" Credential="+accessKey+", Signature="+signature(secretKey, req.Method, req.URL.RequestURI(), timestamp, nonce, bodyHash))
+	return nil
+}
+
+// Verifier checks the signature of incoming requests
+type Verifier struct {
+	// SecretKey returns the secret key of an access key, ok is false for
+	// unknown access keys
+	SecretKey func(accessKey string) (secretKey string, ok bool)
+
+	// MaxSkew defaults to DefaultMaxSkew
+	MaxSkew time.Duration
+
+	// Now defaults to time.Now
+	Now func() time.Time
+
+	// UseNonce, when set, records the nonce of a request whose signature
+	// matched and returns false if it was already used, the request is then
+	// rejected with ErrReplayed. Nonces have to be remembered for twice MaxSkew.
+	UseNonce func(nonce string, now time.Time) bool
+}
+
+// Verify checks the signature of req and returns the access key it was signed
+// with. The body is read and replaced so that handlers can still read it.
+func (v *Verifier) Verify(req *http.Request) (accessKey string, err error) {
+	auth := req.Header.Get("Authorization")
+	if auth == "" {
+		return "", ErrMissingSignature
+	}
+
+	accessKey, sig, ok := parseAuthorization(auth)
+	if !ok {
+		return "", ErrMalformed
+	}
+
+	timestamp := req.Header.Get(HeaderTimestamp)
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	nonce := req.Header.Get(HeaderNonce)
+	if nonce == "" {
+		return "", ErrMalformed
+	}
+
+	maxSkew, now := v.MaxSkew, time.Now
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	if v.Now != nil {
+		now = v.Now
+	}
+	verifiedAt := now()
+	if skew := verifiedAt.Sub(time.Unix(signedAt, 0)); skew > maxSkew || skew < -maxSkew {
+		return "", ErrExpired
+	}
+
+	secretKey, ok := v.SecretKey(accessKey)
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	bodyHash := hashBody(body)
+	if req.Header.Get(HeaderContentSHA256) != bodyHash {
+		return "", ErrBodyMismatch
+	}
+
+	expected := signature(secretKey, req.Method, req.URL.RequestURI(), timestamp, nonce, bodyHash)
+	if !hmac.Equal([]byte(sig), []byte(expected)) {
+		return "", ErrBadSignature
+	}
+
+	// only signed nonces are remembered so that forged requests cannot fill it
+	if v.UseNonce != nil && !v.UseNonce(nonce, verifiedAt) {
+		return "", ErrReplayed
+	}
+
+	return accessKey, nil
+}
+
+// Middleware rejects requests which fail Verify with 401
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := v.Verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// StaticKeys returns a SecretKey lookup over a fixed access key to secret key map
+func StaticKeys(keys map[string]string) func(string) (string, bool) {
+	return func(accessKey string) (string, bool) {
+		secretKey, ok := keys[accessKey]
+		return secretKey, ok
+	}
+}
+
+func signature(secretKey, method, requestURI, timestamp, nonce, bodyHash string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(strings.Join([]string{method, requestURI, timestamp, nonce, bodyHash}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("cannot sign a request body which cannot be read twice")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// parseAuthorization reads "Sailor-HMAC-SHA256 Credential=ak, Signature=sig"
+func parseAuthorization(auth string) (accessKey, sig string, ok bool) {
+	params, ok := strings.CutPrefix(auth, Scheme+" ")
+	if !ok {
+		return "", "", false
+	}
+
+	for _, param := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch k {
+		case "Credential":
+			accessKey = v
+		case "Signature":
+			sig = v
+		}
+	}
+
+	return accessKey, sig, accessKey != "" && sig != ""
+}
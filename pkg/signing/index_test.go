@@ -0,0 +1,195 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := &Verifier{
+		SecretKey: StaticKeys(map[string]string{"ak": "sk"}),
+		Now:       func() time.Time { return now },
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://sailor/api/v1/bulk/ns/app?resource=config", strings.NewReader("body"))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("body")), nil }
+	if err := Sign(req, "ak", "sk", now); err != nil {
+		t.Fatal(err)
+	}
+
+	accessKey, err := verifier.Verify(req)
+	if err != nil || accessKey != "ak" {
+		t.Fatalf("expected ak to verify got %q %v", accessKey, err)
+	}
+	if body, _ := io.ReadAll(req.Body); string(body) != "body" {
+		t.Errorf("expected the body to be readable after Verify got %q", body)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := &Verifier{
+		SecretKey: StaticKeys(map[string]string{"ak": "sk", "other": "other"}),
+		Now:       func() time.Time { return now },
+	}
+
+	signed := func(method, target, accessKey, secretKey string, at time.Time) *http.Request {
+		req := httptest.NewRequest(method, target, nil)
+		if err := Sign(req, accessKey, secretKey, at); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tamperedPath := signed(http.MethodGet, "http://sailor/api/v1/resource/ns/app/config", "ak", "sk", now)
+	tamperedPath.URL.Path = "/api/v1/resource/ns/other/config"
+
+	tamperedQuery := signed(http.MethodGet, "http://sailor/api/v1/bulk/ns/app?resource=config", "ak", "sk", now)
+	tamperedQuery.URL.RawQuery = "resource=secret"
+
+	tamperedMethod := signed(http.MethodGet, "http://sailor/api/v1/resource/ns/app/config", "ak", "sk", now)
+	tamperedMethod.Method = http.MethodDelete
+
+	tamperedBody := signed(http.MethodPost, "http://sailor/api/v1/resource/ns/app/config", "ak", "sk", now)
+	tamperedBody.Body = io.NopCloser(strings.NewReader("injected"))
+
+	tamperedNonce := signed(http.MethodGet, "http://sailor/", "ak", "sk", now)
+	tamperedNonce.Header.Set(HeaderNonce, "other")
+
+	noNonce := signed(http.MethodGet, "http://sailor/", "ak", "sk", now)
+	noNonce.Header.Del(HeaderNonce)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		err  error
+	}{
+		{"unsigned", httptest.NewRequest(http.MethodGet, "http://sailor/", nil), ErrMissingSignature},
+		{"unknown key", signed(http.MethodGet, "http://sailor/", "nobody", "sk", now), ErrUnknownKey},
+		{"wrong secret", signed(http.MethodGet, "http://sailor/", "ak", "other", now), ErrBadSignature},
+		{"other key id", signed(http.MethodGet, "http://sailor/", "other", "sk", now), ErrBadSignature},
+		{"stale", signed(http.MethodGet, "http://sailor/", "ak", "sk", now.Add(-10*time.Minute)), ErrExpired},
+		{"path", tamperedPath, ErrBadSignature},
+		{"query", tamperedQuery, ErrBadSignature},
+		{"method", tamperedMethod, ErrBadSignature},
+		{"body", tamperedBody, ErrBodyMismatch},
+		{"nonce", tamperedNonce, ErrBadSignature},
+		{"no nonce", noNonce, ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.req); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v got %v", tt.err, err)
+			}
+		})
+	}
+
+	malformed := httptest.NewRequest(http.MethodGet, "http://sailor/", nil)
+	malformed.Header.Set("Authorization", "Bearer token")
+	if _, err := verifier.Verify(malformed); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected malformed got %v", err)
+	}
+}
+
+func TestVerifyUseNonce(t *testing.T) {
+	seen := map[string]bool{}
+	verifier := &Verifier{
+		SecretKey: StaticKeys(map[string]string{"ak": "sk"}),
+		UseNonce: func(nonce string, _ time.Time) bool {
+			if seen[nonce] {
+				return false
+			}
+			seen[nonce] = true
+			return true
+		},
+	}
+
+	signed := func(nonce string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://sailor/sailor/webhook", nil)
+		req.Header.Set(HeaderNonce, nonce)
+		if err := Sign(req, "ak", "sk", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	if _, err := verifier.Verify(signed("n1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(signed("n1")); !errors.Is(err, ErrReplayed) {
+		t.Errorf("expected a reused nonce to be rejected got %v", err)
+	}
+
+	// generated nonces differ from one request to the next
+	a, b := signed(""), signed("")
+	if a.Header.Get(HeaderNonce) == "" || a.Header.Get(HeaderNonce) == b.Header.Get(HeaderNonce) {
+		t.Errorf("expected unique nonces got %q and %q", a.Header.Get(HeaderNonce), b.Header.Get(HeaderNonce))
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier := &Verifier{SecretKey: StaticKeys(map[string]string{"ak": "sk"})}
+	server := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/resource/ns/app/config", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unsigned request got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/api/v1/resource/ns/app/config", nil)
+	Sign(req, "ak", "sk", time.Now())
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected a signed request through got %d", resp.StatusCode)
+	}
+}
+
+func TestVerifyPayload(t *testing.T) {
+	oldPub, oldKey, _ := ed25519.GenerateKey(nil)
+	newPub, newKey, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	payload := []byte(`{"app":"test"}`)
+
+	keys := []ed25519.PublicKey{oldPub, newPub}
+	if !VerifyPayload(keys, payload, SignPayload(oldKey, payload)) || !VerifyPayload(keys, payload, SignPayload(newKey, payload)+"\n") {
+		t.Error("expected a signature by any trusted key to verify")
+	}
+
+	cases := map[string]struct {
+		keys    []ed25519.PublicKey
+		payload []byte
+		sig     string
+	}{
+		"untrusted key":    {[]ed25519.PublicKey{otherPub}, payload, SignPayload(oldKey, payload)},
+		"tampered payload": {keys, []byte(`{"app":"evil"}`), SignPayload(oldKey, payload)},
+		"empty signature":  {keys, payload, ""},
+		"not base64":       {keys, payload, "not base64!"},
+		"short signature":  {keys, payload, "c2hvcnQ="},
+		"no keys":          {nil, payload, SignPayload(oldKey, payload)},
+	}
+	for name, tc := range cases {
+		if VerifyPayload(tc.keys, tc.payload, tc.sig) {
+			t.Errorf("%s: expected the signature to be rejected", name)
+		}
+	}
+}
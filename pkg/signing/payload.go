@@ -0,0 +1,55 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	// HeaderPayloadSignature carries the base64 ed25519 signature of the
+	// response body
+	HeaderPayloadSignature = "X-Sailor-Payload-Signature"
+
+	// PayloadSignatureSuffix is appended to the URL of a fallback file to get
+	// its detached signature, a file holding the base64 ed25519 signature
+	PayloadSignatureSuffix = ".sig"
+)
+
+// SignPayload returns the base64 ed25519 signature of the payload, as sent in
+// HeaderPayloadSignature or written to a detached signature file
+func SignPayload(key ed25519.PrivateKey, payload []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+}
+
+// VerifyPayload reports whether sig is a signature of the payload by any of
+// the keys, listing both the old and the new key allows rotating them
+func VerifyPayload(keys []ed25519.PublicKey, payload []byte, sig string) bool {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig))
+	if err != nil || len(b) != ed25519.SignatureSize {
+		return false
+	}
+
+	for _, key := range keys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, payload, b) {
+			return true
+		}
+	}
+	return false
+}
@@ -53,6 +53,7 @@ func TestPullConfig(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -111,6 +112,7 @@ func TestPullMisc(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
@@ -182,6 +184,7 @@ func TestPullSecrets(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if err = consumer.Start(); err != nil {
 		t.Error(err)
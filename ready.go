@@ -0,0 +1,117 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// storedSignal returns a channel which is closed the next time a resource is
+// stored
+func (c *Consumer[C, S]) storedSignal() <-chan struct{} {
+	c.readyMu.Lock()
+	defer c.readyMu.Unlock()
+	if c.stored == nil {
+		c.stored = make(chan struct{})
+	}
+	return c.stored
+}
+
+// signalStored wakes up everyone waiting for a resource to be stored, it is
+// also called when a resource fails so first reads stop waiting for it
+func (c *Consumer[C, S]) signalStored() {
+	c.readyMu.Lock()
+	defer c.readyMu.Unlock()
+	if c.stored != nil {
+		close(c.stored)
+		c.stored = nil
+	}
+}
+
+func (c *Consumer[C, S]) setStartErr(err error) {
+	c.readyMu.Lock()
+	defer c.readyMu.Unlock()
+	c.startErr = err
+}
+
+// requiredLoaded tells if every required resource was loaded at least once
+func (c *Consumer[C, S]) requiredLoaded() bool {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+
+	for _, res := range c.opts.Resources {
+		if !res.IsRequired() {
+			continue
+		}
+		if st, ok := c.states[resourceKey{res.Def.Kind, res.Def.Name}]; !ok || !st.loaded {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitReady blocks until every required resource is loaded or ctx is done. It
+// is meant to be used with InitOption.AsyncStart and returns right away once a
+// synchronous Start succeeded. When ctx is done the error also carries why the
+// resources could not be loaded at start, if known.
+func (c *Consumer[C, S]) WaitReady(ctx context.Context) error {
+	for {
+		stored := c.storedSignal()
+		if c.requiredLoaded() {
+			return nil
+		}
+
+		select {
+		case <-stored:
+		case <-ctx.Done():
+			c.readyMu.Lock()
+			startErr := c.startErr
+			c.readyMu.Unlock()
+			return errors.Join(ctx.Err(), startErr)
+		}
+	}
+}
+
+// waitFirstRead waits up to InitOption.FirstReadTimeout for loaded to become
+// true and reports if it did. Only a resource defined in InitOption.Resources is
+// waited for. A failed attempt does not end the wait as the fallback or a retry
+// may still load the resource in time.
+func (c *Consumer[C, S]) waitFirstRead(kind opts.ResourceKind, name string, loaded func() bool) bool {
+	if c.opts.FirstReadTimeout <= 0 || !c.manages(kind, name) {
+		return loaded()
+	}
+
+	timer := time.NewTimer(c.opts.FirstReadTimeout)
+	defer timer.Stop()
+	for {
+		stored := c.storedSignal()
+		if loaded() {
+			return true
+		}
+
+		select {
+		case <-stored:
+		case <-timer.C:
+			return false
+		case <-c.ctx.Done():
+			return false
+		}
+	}
+}
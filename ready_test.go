@@ -0,0 +1,205 @@
+package sailor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestAsyncStart(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetLatency(100 * time.Millisecond)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		AsyncStart: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	started := time.Now()
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(started); took > 50*time.Millisecond {
+		t.Errorf("expected start to return at once, took %s", took)
+	}
+
+	if _, err := consumer.Get(); !errors.Is(err, ErrConfigsNotLoaded) {
+		t.Errorf("expected config not to be loaded yet got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := consumer.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if config, err := consumer.Get(); err != nil || config["app"] != "test" {
+		t.Errorf("unexpected config %v %v", config, err)
+	}
+}
+
+func TestAsyncStartRetriesRequired(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetStatus(http.StatusServiceUnavailable)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true, PullInterval: 5 * time.Millisecond},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		AsyncStart: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = consumer.WaitReady(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrFetchFallbackFailed) {
+		t.Errorf("expected deadline and start errors got %v", err)
+	}
+
+	server.SetStatus(0)
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := consumer.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFirstReadTimeout(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetLatency(50 * time.Millisecond)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+
+	newConsumer := func(timeout time.Duration) *Consumer[map[string]string, any] {
+		consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+			Resources: []opts.ResourceOption{
+				{
+					Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+					FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+				},
+				{
+					Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+					FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+				},
+			},
+			Connection:       server.Connection("test", "test", "ak", "sk"),
+			AsyncStart:       true,
+			FirstReadTimeout: timeout,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { consumer.Close() })
+		if err := consumer.Start(); err != nil {
+			t.Fatal(err)
+		}
+		return consumer
+	}
+
+	consumer := newConsumer(time.Second)
+	if config, err := consumer.Get(); err != nil || config["app"] != "test" {
+		t.Errorf("expected Get to wait for the first load got %v %v", config, err)
+	}
+
+	// certs never loads, the read gives up after the timeout
+	consumer = newConsumer(20 * time.Millisecond)
+	started := time.Now()
+	if _, err := consumer.GetMisc("certs"); !errors.Is(err, ErrMiscNotLoaded) {
+		t.Errorf("expected misc not to be loaded got %v", err)
+	}
+	if took := time.Since(started); took < 20*time.Millisecond {
+		t.Errorf("expected GetMisc to wait, took %s", took)
+	}
+}
+
+func TestFirstReadWaitsForRetry(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+	server.SetStatus(http.StatusServiceUnavailable)
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{{
+			Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 20 * time.Millisecond},
+		}},
+		Connection:       server.Connection("test", "test", "ak", "sk"),
+		AsyncStart:       true,
+		FirstReadTimeout: 2 * time.Second,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first attempts fail, the read keeps waiting for the retry
+	sailortest.Eventually(t, time.Second, func() bool { return consumer.Status().Resources[0].LastError != "" })
+	time.AfterFunc(100*time.Millisecond, func() { server.SetStatus(0) })
+	if config, err := consumer.Get(); err != nil || config["app"] != "test" {
+		t.Errorf("expected Get to wait for the retry got %v %v", config, err)
+	}
+}
+
+func TestFirstReadDoesNotWaitForUnmanaged(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetMisc("test", "test", "certs", []byte("pem"))
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection:       server.Connection("test", "test", "ak", "sk"),
+		FirstReadTimeout: time.Second,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	if _, err := consumer.Get(); !errors.Is(err, ErrConfigsNotLoaded) {
+		t.Errorf("expected configs not to be loaded got %v", err)
+	}
+	if _, err := consumer.GetMisc("missing"); !errors.Is(err, ErrMiscNotLoaded) {
+		t.Errorf("expected misc not to be loaded got %v", err)
+	}
+	if _, err := consumer.GetSecret(); !errors.Is(err, ErrSecretsNotLoaded) {
+		t.Errorf("expected secrets not to be loaded got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	consumer.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if took := time.Since(started); took > 100*time.Millisecond {
+		t.Errorf("expected reads not to wait, took %s", took)
+	}
+}
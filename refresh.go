@@ -0,0 +1,108 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// RefreshResult is the outcome of refreshing a single resource
+type RefreshResult struct {
+	Kind    opts.ResourceKind
+	Name    string
+	Source  Source
+	Version string
+	Err     error
+}
+
+// refreshKey marks a context as belonging to an explicit refresh
+type refreshKey struct{}
+
+func isRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
+
+// Refresh reloads a single resource right away through the same fetch, fallback
+// and decode pipeline used by Start. Volume resources are re-read, pulled
+// resources are pulled and DEV resources are fetched again from Sailor, bypassing
+// the local cache. name is only used for misc resources.
+func (c *Consumer[C, S]) Refresh(ctx context.Context, kind opts.ResourceKind, name string) (RefreshResult, error) {
+	for i := range c.opts.Resources {
+		res := &c.opts.Resources[i]
+		if res.Def.Kind == kind && res.Def.Name == name {
+			result := c.refreshResource(ctx, res)
+			return result, result.Err
+		}
+	}
+
+	return RefreshResult{Kind: kind, Name: name, Err: ErrResourceNotManaged}, ErrResourceNotManaged
+}
+
+// RefreshAll refreshes every resource of the consumer one after another and
+// returns a result for each of them, the returned error joins every failure
+func (c *Consumer[C, S]) RefreshAll(ctx context.Context) ([]RefreshResult, error) {
+	results := make([]RefreshResult, 0, len(c.opts.Resources))
+	var errs []error
+	for i := range c.opts.Resources {
+		result := c.refreshResource(ctx, &c.opts.Resources[i])
+		if result.Err != nil {
+			errs = append(errs, resourceErr(result.Kind, result.Name, result.Err))
+		}
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+func (c *Consumer[C, S]) refreshResource(ctx context.Context, res *opts.ResourceOption) RefreshResult {
+	raw, err := c.loadResource(context.WithValue(ctx, refreshKey{}, true), res, "sailor resource refreshed")
+	return RefreshResult{
+		Kind:    res.Def.Kind,
+		Name:    res.Def.Name,
+		Source:  raw.source,
+		Version: raw.version,
+		Err:     err,
+	}
+}
+
+// refreshOnSIGHUP refreshes every resource each time the process gets SIGHUP,
+// until the consumer is closed
+func (c *Consumer[C, S]) refreshOnSIGHUP() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-signals:
+		}
+
+		c.log().Info("sailor got SIGHUP, refreshing resources")
+		if _, err := c.RefreshAll(c.ctx); err != nil {
+			c.log().Error("sailor refresh on SIGHUP failed", slog.Any("error", err))
+		}
+	}
+}
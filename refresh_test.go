@@ -0,0 +1,153 @@
+package sailor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestRefresh(t *testing.T) {
+	type DummyConfig struct {
+		App string `json:"app"`
+	}
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", DummyConfig{App: "first"})
+	server.SetMisc("test", "test", "certs", []byte("first"))
+
+	consumer, err := NewConsumer[DummyConfig, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetConfig("test", "test", DummyConfig{App: "second"})
+	result, err := consumer.Refresh(context.Background(), opts.CONFIGS, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Source != SourcePull || result.Version != "v2" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	config, _ := consumer.Get()
+	if config.App != "second" {
+		t.Errorf("expected second got %s", config.App)
+	}
+
+	server.SetMisc("test", "test", "certs", []byte("second"))
+	results, err := consumer.RefreshAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[1].Kind != opts.MISC || results[1].Version != "v2" {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	misc, _ := consumer.GetMisc("certs")
+	if string(misc) != "second" {
+		t.Errorf("expected second got %s", misc)
+	}
+}
+
+func TestRefreshErrors(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "first"})
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := consumer.Refresh(context.Background(), opts.SECRETS, ""); !errors.Is(err, ErrResourceNotManaged) {
+		t.Errorf("expected ErrResourceNotManaged got %v", err)
+	}
+
+	// Sailor is down and there is no fallback, the refresh must fail and the
+	// config in use must be kept
+	server.SetStatus(500)
+	results, err := consumer.RefreshAll(context.Background())
+	if !errors.Is(err, ErrFetchFallbackFailed) || len(results) != 1 || results[0].Err == nil {
+		t.Errorf("expected a failed refresh got %+v %v", results, err)
+	}
+
+	config, _ := consumer.Get()
+	if config["app"] != "first" {
+		t.Errorf("expected config to be kept got %+v", config)
+	}
+}
+
+func TestRefreshOnSIGHUPStopsOnClose(t *testing.T) {
+	// keeps SIGHUP from terminating the test binary once the consumer stops
+	// listening
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:      []opts.ResourceOption{configPullOnce()},
+		Connection:     server.Connection("test", "test", "ak", "sk"),
+		ReloadOnSIGHUP: true,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetConfig("test", "test", map[string]string{"release": "2"})
+	sailortest.Eventually(t, time.Second, func() bool {
+		self.Signal(syscall.SIGHUP)
+		config, _ := consumer.Get()
+		return config["release"] == "2"
+	})
+
+	consumer.Close()
+	time.Sleep(20 * time.Millisecond)
+	server.SetConfig("test", "test", map[string]string{"release": "3"})
+	self.Signal(syscall.SIGHUP)
+	time.Sleep(100 * time.Millisecond)
+	assertRelease(t, consumer, "2")
+}
@@ -0,0 +1,172 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+const (
+	defaultReportInterval    = time.Minute
+	defaultReportBatchWindow = time.Second
+
+	// reportTimeout bounds a single report, a slow Sailor must not pile them up
+	reportTimeout = 10 * time.Second
+)
+
+// reporter sends the applied versions to Sailor in the background. Changes
+// only flag that a report is due so that loading a resource never waits on it.
+type reporter struct {
+	instanceID  string
+	interval    time.Duration
+	batchWindow time.Duration
+
+	// changed holds at most one pending signal, the changes it stands for are
+	// all sent in the next report
+	changed chan struct{}
+}
+
+func newReporter(opt *opts.ReportOption) *reporter {
+	if opt == nil {
+		return nil
+	}
+
+	r := &reporter{
+		instanceID:  opt.InstanceID,
+		interval:    opt.Interval,
+		batchWindow: opt.BatchWindow,
+		changed:     make(chan struct{}, 1),
+	}
+	if r.instanceID == "" {
+		r.instanceID, _ = os.Hostname()
+	}
+	if r.interval <= 0 {
+		r.interval = defaultReportInterval
+	}
+	if r.batchWindow <= 0 {
+		r.batchWindow = defaultReportBatchWindow
+	}
+	return r
+}
+
+// reportChanged flags that a report is due without ever blocking
+func (c *Consumer[C, S]) reportChanged() {
+	if c.reporter == nil {
+		return
+	}
+	select {
+	case c.reporter.changed <- struct{}{}:
+	default:
+	}
+}
+
+// runReporter sends a report after every batch of changes and every interval
+// until the consumer is closed
+func (c *Consumer[C, S]) runReporter() {
+	r := c.reporter
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-r.changed:
+			// changes arriving meanwhile are part of this report
+			if !c.sleep(r.batchWindow) {
+				return
+			}
+			select {
+			case <-r.changed:
+			default:
+			}
+		case <-ticker.C:
+		}
+
+		if err := c.sendReport(); err != nil {
+			c.log().Debug("sailor report not sent", slog.Any("error", err))
+		}
+	}
+}
+
+// report is the applied state of every resource
+func (c *Consumer[C, S]) report() opts.SailorReport {
+	report := opts.SailorReport{
+		Namespace:  c.opts.Connection.Namespace,
+		App:        c.opts.Connection.App,
+		InstanceID: c.reporter.instanceID,
+		At:         time.Now(),
+	}
+	for _, rs := range c.Status().Resources {
+		report.Resources = append(report.Resources, opts.SailorReportResource{
+			Kind:      rs.Kind,
+			Name:      rs.Name,
+			Loaded:    rs.Loaded,
+			Version:   rs.Version,
+			Source:    string(rs.Source),
+			AppliedAt: rs.AppliedAt,
+			Pinned:    rs.Pinned,
+		})
+	}
+	return report
+}
+
+func (c *Consumer[C, S]) sendReport() error {
+	body, err := json.Marshal(c.report())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.reportURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// reports are best effort and stay out of the circuit breaker, a failing
+	// report endpoint must not stop resources from being fetched
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("report endpoint: %w", c.statusError(resp.StatusCode))
+	}
+	return nil
+}
+
+// reportURL is the Sailor API endpoint receiving the applied versions
+func (c *Consumer[C, S]) reportURL() string {
+	return fmt.Sprintf("%s/api/v1/report/%s/%s",
+		c.opts.Connection.Addr,
+		c.opts.Connection.Namespace,
+		c.opts.Connection.App,
+	)
+}
@@ -0,0 +1,193 @@
+package sailor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestReportOnChange(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Report: &opts.ReportOption{
+			InstanceID:  "pod-1",
+			Interval:    time.Hour,
+			BatchWindow: 20 * time.Millisecond,
+		},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.Eventually(t, time.Second, func() bool { return len(server.Reports("test", "test")) == 1 })
+	report := server.Reports("test", "test")[0]
+	if report.Namespace != "test" || report.App != "test" || report.InstanceID != "pod-1" || len(report.Resources) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if rs := report.Resources[0]; rs.Kind != opts.CONFIGS || !rs.Loaded || rs.Version != "v1" || rs.Source != string(SourcePull) || rs.AppliedAt.IsZero() {
+		t.Errorf("unexpected resource report %+v", rs)
+	}
+
+	server.SetConfig("test", "test", map[string]string{"release": "2"})
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.Eventually(t, time.Second, func() bool { return len(server.Reports("test", "test")) == 2 })
+	if rs := server.Reports("test", "test")[1].Resources[0]; rs.Version != "v2" {
+		t.Errorf("expected the new version to be reported, got %+v", rs)
+	}
+}
+
+func TestReportAppliedAtOnChange(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Report:     &opts.ReportOption{Interval: time.Hour, BatchWindow: time.Millisecond},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	applied := consumer.report().Resources[0].AppliedAt
+
+	// fetching the same version again is not applying it
+	time.Sleep(10 * time.Millisecond)
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := consumer.report().Resources[0].AppliedAt; !got.Equal(applied) {
+		t.Errorf("expected AppliedAt to stay %v for the same version, got %v", applied, got)
+	}
+
+	server.SetConfig("test", "test", map[string]string{"release": "2"})
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := consumer.report().Resources[0].AppliedAt; !got.After(applied) {
+		t.Errorf("expected AppliedAt to move past %v for a new version, got %v", applied, got)
+	}
+}
+
+func TestReportBatchesChanges(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Report: &opts.ReportOption{
+			Interval:    time.Hour,
+			BatchWindow: 50 * time.Millisecond,
+		},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	sailortest.Eventually(t, time.Second, func() bool { return len(server.Reports("test", "test")) == 1 })
+
+	for range 10 {
+		consumer.reportChanged()
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if got := len(server.Reports("test", "test")); got != 2 {
+		t.Errorf("expected the changes to be sent in a single report, got %d reports", got)
+	}
+}
+
+func TestReportHeartbeat(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Report:     &opts.ReportOption{Interval: 20 * time.Millisecond, BatchWindow: time.Millisecond},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	sailortest.Eventually(t, time.Second, func() bool { return len(server.Reports("test", "test")) >= 3 })
+}
+
+func TestReportBestEffort(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Report:     &opts.ReportOption{Interval: 20 * time.Millisecond, BatchWindow: time.Millisecond},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failing report endpoint does not get in the way of serving resources
+	server.SetStatus(500)
+	time.Sleep(60 * time.Millisecond)
+	assertRelease(t, consumer, "1")
+
+	server.SetStatus(0)
+	before := len(server.Reports("test", "test"))
+	sailortest.Eventually(t, time.Second, func() bool { return len(server.Reports("test", "test")) > before })
+}
+
+func TestReportDisabled(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	consumer.reportChanged()
+	time.Sleep(50 * time.Millisecond)
+
+	if got := len(server.Reports("test", "test")); got != 0 {
+		t.Errorf("expected no report, got %d", got)
+	}
+}
+
+func TestReportOutsideCircuit(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:      []opts.ResourceOption{configPullOnce()},
+		Connection:     server.Connection("test", "test", "ak", "sk"),
+		Report:         &opts.ReportOption{Interval: 10 * time.Millisecond, BatchWindow: time.Millisecond},
+		CircuitBreaker: &opts.CircuitBreakerOption{FailureThreshold: 2, OpenTimeout: time.Hour},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// failed reports do not open the circuit for fetching resources
+	server.SetStatus(500)
+	before := server.Requests("/api/v1/report/test/test")
+	sailortest.Eventually(t, time.Second, func() bool { return server.Requests("/api/v1/report/test/test") >= before+3 })
+	if st := consumer.Status().Circuit; st == nil || st.State != CircuitClosed || st.ConsecutiveFailures != 0 {
+		t.Fatalf("expected the circuit to stay closed, got %+v", st)
+	}
+
+	server.SetStatus(0)
+	server.SetConfig("test", "test", map[string]string{"release": "2"})
+	if _, err := consumer.Refresh(context.Background(), opts.CONFIGS, ""); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "2")
+}
@@ -16,21 +16,25 @@
 package sailor
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/sailorhq/sailor-go/pkg/metrics"
 	"github.com/sailorhq/sailor-go/pkg/opts"
-	"github.com/sailorhq/sailor/pkg/vault"
+	"github.com/sailorhq/sailor-go/pkg/signing"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -38,6 +42,23 @@ import (
 const (
 	ENV_SAILOR_URI               = "SAILOR_URI"
 	ENV_SAILOR_FALLBACK_BASE_URL = "SAILOR_FALLBACK_BASE_URL"
+
+	// ENV_SAILOR_ENV overrides the env used from ~/.sailor/config and
+	// ENV_SAILOR_CONFIG the path of the file itself
+	ENV_SAILOR_ENV    = "SAILOR_ENV"
+	ENV_SAILOR_CONFIG = "SAILOR_CONFIG"
+
+	// defaultPullInterval is used when a PULL resource has no PullInterval
+	defaultPullInterval = 10 * time.Second
+
+	// maxParallelFetches bounds how many resources Start loads at once
+	maxParallelFetches = 4
+
+	// resources which failed to load are retried with a backoff starting at
+	// optionalRetryMinBackoff and doubling up to their PullInterval, or up to
+	// optionalRetryMaxBackoff when they are not pulled
+	optionalRetryMinBackoff = time.Second
+	optionalRetryMaxBackoff = 30 * time.Second
 )
 
 type Consumer[C any, S any] struct {
@@ -45,6 +66,18 @@ type Consumer[C any, S any] struct {
 
 	sailorClient *http.Client
 
+	// endpoints fails requests over across the Sailor replicas, nil when the
+	// connection has a single one
+	endpoints *endpointPool
+
+	// breaker stops calling Sailor while it keeps failing, nil when disabled
+	breaker *circuitBreaker
+
+	// history keeps the last decoded versions of every resource for Rollback,
+	// the state of a stored resource is recorded under historyMu too
+	historyMu sync.Mutex
+	history   map[resourceKey]*resourceHistory
+
 	// configs are values which represent ConfigMap or AppConfig
 	configs atomic.Pointer[C]
 
@@ -55,14 +88,57 @@ type Consumer[C any, S any] struct {
 	// @NOTE: the caller consuming this resource must know the type and how to
 	// make sense of them
 	misc atomic.Pointer[map[string][]byte]
+	// miscMu serializes the copy-on-write updates of misc
+	miscMu sync.Mutex
 
 	// watcher is a file watcher for any watchable resource defined during
-	// connection with a ResourceOption
+	// connection with a ResourceOption, created when the first watchable
+	// resource is registered, for example k8s ConfigMap
+	watchMu sync.Mutex
 	watcher *fsnotify.Watcher
 
-	// hasWatchableResource says to the consumer to init watcher only if
-	// there is any watchable resource defined, for example k8s ConfigMap
-	hasWatchableResource bool
+	// ctx is cancelled by Close and stops every background goroutine
+	ctx  context.Context
+	stop context.CancelFunc
+
+	// logger receives the structured events of the consumer, see InitOption.Logging
+	logger *slog.Logger
+
+	// metrics receives the measurements of every fetch and reload, see
+	// InitOption.Metrics
+	metrics metrics.Metrics
+
+	// states keeps the load state of every resource for Status and events the
+	// most recent reloads for the DebugHandler, both are guarded by statesMu
+	statesMu sync.Mutex
+	states   map[resourceKey]*resourceState
+	events   []ReloadEvent
+
+	// stored is closed and replaced every time a resource is stored or fails,
+	// startErr keeps why an async start could not load the required resources
+	readyMu  sync.Mutex
+	stored   chan struct{}
+	startErr error
+
+	// token replaces Connection.Token once it was refreshed from ~/.sailor/config
+	token atomic.Pointer[string]
+
+	// webhookNonces rejects replayed webhook notifications
+	webhookNonces webhookNonces
+
+	// streamResources are the STREAM resources kept up to date by watchStream
+	streamResources []*opts.ResourceOption
+
+	// listeners are called on every resource change, see OnChange
+	listenersMu sync.RWMutex
+	listeners   []func(Change)
+
+	// reporter sends the applied versions to Sailor, nil when not enabled
+	reporter *reporter
+
+	// secretEncoding is the layout of the secret resource, taken from the
+	// SECRETS ResourceOption when the consumer starts
+	secretEncoding opts.SecretEncoding
 }
 
 // watcherInfo is a union of the resource which needs to be watched
@@ -77,7 +153,7 @@ type watcherInfo struct {
 	name string
 
 	// isDev marks this entry as a DEV-mode cached resource so the watcher
-	// uses DEV-style logging instead of the plain [sailor] logs
+	// reports it with the dev source
 	isDev bool
 }
 
@@ -86,6 +162,10 @@ type watcherInfo struct {
 // @value = metadata of the value
 var watcherFileNameResourceMap = map[string]watcherInfo{}
 
+// watcherMu guards watcherFileNameResourceMap as optional resources may be
+// registered after Start returned
+var watcherMu sync.RWMutex
+
 // NewConsumer function initializes the sailor consumer with the given ResourceOption(s).
 // where:
 //
@@ -103,6 +183,14 @@ func NewConsumer[C any, S any](initOpts opts.InitOption) (*Consumer[C, S], error
 		return nil, ErrNewConsumerEmptyResourceList
 	}
 
+	consumer.ctx, consumer.stop = context.WithCancel(context.Background())
+
+	consumer.logger = newLogger(initOpts)
+	consumer.metrics = initOpts.Metrics
+	if consumer.metrics == nil {
+		consumer.metrics = metrics.NewRegistry()
+	}
+
 	// if watch option is not provided we by default watch for resource changes
 	if initOpts.Watch == nil {
 		watch := true
@@ -116,9 +204,7 @@ func NewConsumer[C any, S any](initOpts opts.InitOption) (*Consumer[C, S], error
 			return nil, err
 		}
 		initOpts.Connection = conn
-		consumer.opts = initOpts
-		consumer.sailorClient = &http.Client{}
-		return &consumer, nil
+		return consumer.connect(initOpts)
 	}
 
 	// Local developer config from ~/.sailor/config
@@ -133,10 +219,8 @@ func NewConsumer[C any, S any](initOpts opts.InitOption) (*Consumer[C, S], error
 			return nil, err
 		}
 		initOpts.Connection = conn
-		devLogBanner(conn.Env, conn.Addr, conn.Namespace, conn.App)
-		consumer.opts = initOpts
-		consumer.sailorClient = &http.Client{}
-		return &consumer, nil
+		consumer.devLogConnected(conn)
+		return consumer.connect(initOpts)
 	}
 
 	// URI provided programmatically via Connection.URI
@@ -146,540 +230,663 @@ func NewConsumer[C any, S any](initOpts opts.InitOption) (*Consumer[C, S], error
 			return nil, err
 		}
 		initOpts.Connection = conn
-		consumer.opts = initOpts
-		consumer.sailorClient = &http.Client{}
-		return &consumer, nil
+		return consumer.connect(initOpts)
 	}
 
 	// Direct programmatic connection with all fields set
 	if initOpts.Connection != nil {
-		consumer.opts = initOpts
-		consumer.sailorClient = &http.Client{}
-		return &consumer, nil
+		return consumer.connect(initOpts)
 	}
 
 	return nil, ErrNewConsumerNoSailorURI
 }
 
+// Start loads every resource concurrently and keeps them up to date. It fails
+// with the errors of all the required resources which could not be loaded,
+// optional ones are retried in the background until they load.
+//
+// With InitOption.AsyncStart it returns at once and loads in the background,
+// required resources which fail are retried like optional ones, see WaitReady.
 func (c *Consumer[C, S]) Start() error {
 	// TODO :: check if this is needed and if we can use atomic.Pointer here as well
 	c.misc.Store(&map[string][]byte{})
 
-	c.watcher, _ = fsnotify.NewWatcher()
+	if c.opts.AsyncStart {
+		go c.start(true)
+		return nil
+	}
+
+	return c.start(false)
+}
+
+// start loads the resources, when async the failures of required resources are
+// kept for WaitReady and retried instead of being returned
+func (c *Consumer[C, S]) start(async bool) error {
+
+	// pulled resources are fetched together up front instead of one request
+	// after the other
+	var pulled []*opts.ResourceOption
+	for i := range c.opts.Resources {
+		res := &c.opts.Resources[i]
+		if res.Def.Kind == opts.SECRETS {
+			c.secretEncoding = res.Def.SecretEncoding
+		}
+		if res.FetchDef.Fetch == opts.PULL || res.FetchDef.Fetch == opts.STREAM {
+			pulled = append(pulled, res)
+		}
+	}
+
+	ctx := c.ctx
+	if len(pulled) > 1 {
+		ctx = context.WithValue(ctx, prefetchKey{}, c.prefetchBulk(ctx, pulled))
+	}
+
+	loaded := c.loadAll(ctx)
+
+	var errs []error
+	for i, res := range c.opts.Resources {
+		if loaded[i].err != nil && res.IsRequired() {
+			errs = append(errs, resourceErr(res.Def.Kind, res.Def.Name, loaded[i].err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		if !async {
+			return err
+		}
+		c.setStartErr(err)
+	}
 
 	// we will check what resources are required and how to manage them
-	for _, res := range c.opts.Resources {
-		switch res.Def.Kind {
-		case opts.CONFIGS:
-			if err := c.manageConfig(&res); err != nil {
-				return err
-			}
-		case opts.SECRETS:
-			if err := c.manageSecrets(&res); err != nil {
-				return err
-			}
-		case opts.MISC:
-			if err := c.manageMisc(&res); err != nil {
-				return err
+	for i := range c.opts.Resources {
+		res := &c.opts.Resources[i]
+		if res.FetchDef.Fetch == opts.STREAM && !res.FetchDef.Once {
+			// the watch stream keeps the resource up to date and loads it
+			// once connected when it failed to load now
+			c.streamResources = append(c.streamResources, res)
+			continue
+		}
+
+		if err := loaded[i].err; err != nil {
+			c.log().Warn("sailor resource not loaded, retrying in background",
+				slog.String("kind", string(res.Def.Kind)),
+				slog.String("name", res.Def.Name),
+				slog.Any("error", err),
+			)
+			go c.retryResource(res)
+			continue
+		}
+
+		if err := c.keepUpToDate(res, loaded[i].raw); err != nil {
+			if res.IsRequired() && !async {
+				return resourceErr(res.Def.Kind, res.Def.Name, err)
 			}
+			c.log().Warn("sailor cannot keep resource up to date", slog.Any("error", err))
 		}
 	}
 
-	// this means that there are volume mounted resources which needs to be watched
-	// for changes and watching is allowed by the developer
-	if c.hasWatchableResource && *c.opts.Watch {
-		go c.watchForVolumeChanges()
+	if len(c.streamResources) > 0 {
+		go c.watchStream(c.streamResources)
+	}
+
+	if c.opts.ReloadOnSIGHUP {
+		go c.refreshOnSIGHUP()
+	}
+
+	if c.reporter != nil {
+		go c.runReporter()
+		c.reportChanged()
 	}
 
 	return nil
 }
 
+type loadResult struct {
+	raw rawResource
+	err error
+}
+
+// loadAll loads every resource concurrently, at most maxParallelFetches at a
+// time, and returns the outcomes in the order of InitOption.Resources
+func (c *Consumer[C, S]) loadAll(ctx context.Context) []loadResult {
+	results := make([]loadResult, len(c.opts.Resources))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallelFetches)
+	for i := range c.opts.Resources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			raw, err := c.loadResource(ctx, &c.opts.Resources[i], "sailor resource loaded")
+			results[i] = loadResult{raw: raw, err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// retryResource loads the resource with a growing backoff until it succeeds and
+// then keeps it up to date like Start would have. It stops when the consumer is
+// closed.
+func (c *Consumer[C, S]) retryResource(res *opts.ResourceOption) {
+	maxBackoff := optionalRetryMaxBackoff
+	if res.FetchDef.Fetch == opts.PULL || res.FetchDef.Fetch == opts.STREAM {
+		maxBackoff = pullInterval(res)
+	}
+	backoff := min(optionalRetryMinBackoff, maxBackoff)
+
+	for c.sleep(backoff) {
+		backoff = min(backoff*2, maxBackoff)
+
+		raw, err := c.loadResource(c.ctx, res, "sailor resource loaded")
+		if err != nil {
+			continue
+		}
+
+		if err := c.keepUpToDate(res, raw); err != nil {
+			c.log().Warn("sailor cannot keep resource up to date", slog.Any("error", err))
+		}
+		return
+	}
+}
+
 // watchForVolumeChanges checks for all the paths mentioned in ResourceOption(s)
-// which is of kind: Volume.
-func (c *Consumer[C, S]) watchForVolumeChanges() {
+// which is of kind: Volume, until the consumer is closed.
+func (c *Consumer[C, S]) watchForVolumeChanges(watcher *fsnotify.Watcher) {
 	for {
 		select {
-		case event := <-c.watcher.Events:
+		case <-c.ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
 			if event.Has(fsnotify.Chmod) || event.Has(fsnotify.Write) {
-				log.Println("[sailor] got a file modification event")
+				c.log().Debug("sailor got a file modification event", slog.String("path", event.Name))
 				// incase of CHMOD event we will wait for symlinking to happen in k8s environment
 				// since we are not in an hurry to update we will wait for a second and be
 				// consistent instead
-				time.Sleep(1 * time.Second)
+				if !c.sleep(time.Second) {
+					return
+				}
 
-				hasVolume := false
-				for _, wi := range watcherFileNameResourceMap {
+				watcherMu.RLock()
+				watched := maps.Clone(watcherFileNameResourceMap)
+				watcherMu.RUnlock()
+
+				for _, wi := range watched {
 					// TODO :: we need to keep a checksum where it computes the hash
 					// and keeps it in memory for checking if the file has changed or not.
 					// If it is deployed in a volume inside K8s, this uses symlink and
 					// we don't come to know which resource has changed.
-					if !wi.isDev {
-						hasVolume = true
+					source := SourceVolume
+					if wi.isDev {
+						source = SourceDev
 					}
 
-					resBytes, err := os.ReadFile(wi.path)
-					if err != nil {
-						if wi.isDev {
-							devLogReloadError(wi.kind, err.Error())
-						} else {
-							log.Printf("[sailor] %s has changed but unable to read it: %s", wi.kind, err.Error())
+					started := time.Now()
+					var resBytes []byte
+					if wi.isDev {
+						// the DEV cache may be sealed, a copy which does not
+						// open is left for the next fetch to replace
+						var ok bool
+						if resBytes, ok = c.readDevCache(wi.path); !ok {
+							c.log().Debug("sailor dev cache skipped", slog.String("kind", string(wi.kind)), slog.String("name", wi.name), slog.String("path", wi.path))
+							continue
 						}
-						continue
-					}
-
-					if err := c.storeRawResource(resBytes, wi.kind, wi.name); err != nil {
-						if wi.isDev {
-							devLogReloadError(wi.kind, err.Error())
-						} else {
-							log.Printf("[sailor] %s has changed but unable to store it: %s", wi.kind, err.Error())
+					} else {
+						var err error
+						if resBytes, err = os.ReadFile(wi.path); err != nil {
+							c.fetchFailed(started, &ResourceError{Kind: wi.kind, Name: wi.name, Source: source, Stage: StageFetch, URL: wi.path, Err: err})
+							continue
 						}
-						continue
 					}
 
-					if wi.isDev {
-						devLogReloaded(wi.kind, wi.path)
-					}
-				}
-
-				if hasVolume {
-					log.Println("[sailor] processed watchable resources")
+					c.applyResource(rawResource{
+						kind:    wi.kind,
+						name:    wi.name,
+						source:  source,
+						version: resourceVersion(nil, resBytes),
+						started: started,
+						data:    resBytes,
+						url:     wi.path,
+					}, "sailor resource reloaded")
 				}
 			}
-		case err := <-c.watcher.Errors:
-			log.Println(err)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			c.log().Error("sailor watcher error", slog.Any("error", err))
 		}
 	}
 }
 
-// manageConfig manages the config defined inside Sailor for a given namespace and app
-func (c *Consumer[C, S]) manageConfig(res *opts.ResourceOption) error {
+// keepUpToDate sets up how a loaded resource is kept up to date: a watcher for
+// volume and dev resources, a background pull for pulled ones.
+func (c *Consumer[C, S]) keepUpToDate(res *opts.ResourceOption, raw rawResource) error {
 	switch res.FetchDef.Fetch {
 	case opts.VOLUME:
-		// check if file is present in the path
-		resourcePath := fmt.Sprintf("%s/_config", res.Def.Path)
-		configBytes, err := os.ReadFile(resourcePath)
-		if err == nil {
-			if err := c.storeRawResource(configBytes, res.Def.Kind, res.Def.Name); err != nil {
-				return err
-			}
-
-			// add watcher details
-			c.hasWatchableResource = true
-			watcherFileNameResourceMap["_config"] = watcherInfo{kind: opts.CONFIGS, path: resourcePath}
-			// we watch for directory changes as volume mount swaps with symlinks
-			c.watcher.Add(res.Def.Path)
-
+		// only a resource which was found in the volume can be watched, a
+		// fallback resource has nothing to watch
+		if raw.source != SourceVolume {
 			return nil
 		}
 
-		if err := c.fetchFallback(res.Def.Kind, res.Def.Name); err != nil {
-			return err
+		// we watch for directory changes as volume mount swaps with symlinks
+		if err := c.watchFile(volumeFileName(res), res.Def.Path, watcherInfo{kind: res.Def.Kind, path: volumePath(res), name: res.Def.Name}); err != nil {
+			return &ResourceError{Kind: res.Def.Kind, Name: res.Def.Name, Source: SourceVolume, Stage: StageFetch, URL: res.Def.Path, Err: err}
 		}
-
-		return nil
 	case opts.PULL:
-		// we will pull for the latest config with version
-		url := fmt.Sprintf("%s/api/v1/resource/%s/%s/config",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-		)
-
-		resp, err := c.doGet(url)
-		if err == nil {
-			if resp.StatusCode != http.StatusOK {
-				// :goto fallback
-				goto PULL_CONFIG_FALLBACK
-			}
-
-			configBytes, err := io.ReadAll(resp.Body)
-			defer resp.Body.Close()
-			if err != nil {
-				// :goto fallback
-				goto PULL_CONFIG_FALLBACK
-			}
-
-			if err := c.storeRawResource(configBytes, res.Def.Kind, res.Def.Name); err != nil {
-				return err
-			}
-
-			// time to check if we want to pull the resource in background thread
-			if !res.FetchDef.Once {
-				go c.keepPullingResource(res)
-			}
-
-			return nil
-		}
-
-	PULL_CONFIG_FALLBACK:
-		if err := c.fetchFallback(res.Def.Kind, res.Def.Name); err != nil {
-			return err
+		// time to check if we want to pull the resource in background thread
+		if raw.source == SourcePull && !res.FetchDef.Once {
+			go c.keepPullingResource(res)
 		}
-
-		return nil
 	case opts.DEV:
-		cachePath, err := devCachePath(c.opts.Connection, opts.CONFIGS)
-		if err != nil {
-			return err
-		}
-
-		apiURL := fmt.Sprintf("%s/api/v1/resource/%s/%s/config",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-		)
-
-		configBytes, err := c.devLoadOrFetch(apiURL, cachePath, opts.CONFIGS)
+		cachePath, err := devCachePath(c.opts.Connection, res)
 		if err != nil {
-			return err
+			return &ResourceError{Kind: res.Def.Kind, Name: res.Def.Name, Source: SourceDev, Stage: StageFetch, Err: err}
 		}
 
-		if err := c.storeRawResource(configBytes, res.Def.Kind, res.Def.Name); err != nil {
-			return err
-		}
-
-		c.hasWatchableResource = true
-		cacheKey := fmt.Sprintf("dev_%s_%s_config", c.opts.Connection.Namespace, c.opts.Connection.App)
-		watcherFileNameResourceMap[cacheKey] = watcherInfo{kind: opts.CONFIGS, path: cachePath, isDev: true}
+		cacheKey := fmt.Sprintf("dev_%s_%s_%s", c.opts.Connection.Namespace, c.opts.Connection.App, strings.TrimPrefix(volumeFileName(res), "_"))
 		cacheDir := filepath.Dir(cachePath)
-		c.watcher.Add(cacheDir)
-		devLogWatching(opts.CONFIGS, cacheDir)
-
-		return nil
+		if err := c.watchFile(cacheKey, cacheDir, watcherInfo{kind: res.Def.Kind, path: cachePath, name: res.Def.Name, isDev: true}); err != nil {
+			return &ResourceError{Kind: res.Def.Kind, Name: res.Def.Name, Source: SourceDev, Stage: StageFetch, URL: cacheDir, Err: err}
+		}
+		c.devLogWatching(res, cacheDir)
 	}
+
 	return nil
 }
 
-func (c *Consumer[C, S]) manageSecrets(res *opts.ResourceOption) error {
-	switch res.FetchDef.Fetch {
-	case opts.VOLUME:
-		// check if file is present in the path
-		resourcePath := fmt.Sprintf("%s/_secret", res.Def.Path)
-		secretBytes, err := os.ReadFile(resourcePath)
-		if err == nil {
-			if err := c.storeRawResource(secretBytes, res.Def.Kind, res.Def.Name); err != nil {
-				return err
-			}
-
-			// add watcher details
-			c.hasWatchableResource = true
-			watcherFileNameResourceMap["_secret"] = watcherInfo{kind: opts.SECRETS, path: resourcePath}
-			c.watcher.Add(res.Def.Path)
-
-			return nil
-		}
-
-		if err := c.fetchFallback(res.Def.Kind, res.Def.Name); err != nil {
-			return err
-		}
+// watchFile registers the file to be reloaded on changes of dir and starts
+// watching for changes if watching is allowed by the developer
+func (c *Consumer[C, S]) watchFile(key, dir string, wi watcherInfo) error {
+	if !*c.opts.Watch {
+		return nil
+	}
 
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.closed() {
 		return nil
-	case opts.PULL:
-		// we will pull for the latest config with version
-		url := fmt.Sprintf("%s/api/v1/resource/%s/%s/secret",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-		)
-
-		resp, err := c.doGet(url)
-		if err == nil {
-			if resp.StatusCode != http.StatusOK {
-				// goto fallback
-				goto PULL_SECRET_FALLBACK
-			}
+	}
+	if c.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("sailor cannot watch for file changes: %w", err)
+		}
+		c.watcher = watcher
+		go c.watchForVolumeChanges(watcher)
+	}
 
-			secretBytes, err := io.ReadAll(resp.Body)
-			defer resp.Body.Close()
-			if err != nil {
-				// goto fallback
-				goto PULL_SECRET_FALLBACK
-			}
+	watcherMu.Lock()
+	watcherFileNameResourceMap[key] = wi
+	watcherMu.Unlock()
 
-			if err := c.storeRawResource(secretBytes, res.Def.Kind, res.Def.Name); err != nil {
-				return err
-			}
+	return c.watcher.Add(dir)
+}
 
-			// time to check if we want to pull the resource in background thread
-			if !res.FetchDef.Once {
-				go c.keepPullingResource(res)
-			}
+// loadResource fetches the resource from the source defined by its FetchOption
+// and stores it. When the volume or Sailor cannot serve it we fetch it from
+// fallback instead, DEV resources never fall back.
+func (c *Consumer[C, S]) loadResource(ctx context.Context, res *opts.ResourceOption, msg string) (rawResource, error) {
+	var raw rawResource
+	var err error
 
-			return nil
+	switch res.FetchDef.Fetch {
+	case opts.VOLUME:
+		raw, err = c.readVolume(res)
+		if err != nil {
+			c.fallbackActivated(res.Def.Kind, res.Def.Name, SourceVolume)
+			return c.loadFallback(ctx, res, err)
 		}
-
-	PULL_SECRET_FALLBACK:
-		if err := c.fetchFallback(res.Def.Kind, res.Def.Name); err != nil {
-			return err
+	case opts.PULL, opts.STREAM:
+		if p, ok := prefetched(ctx, res); ok {
+			raw = p
+		} else {
+			raw, err = c.pullResource(ctx, res)
 		}
-
-		return nil
-	case opts.DEV:
-		cachePath, err := devCachePath(c.opts.Connection, opts.SECRETS)
 		if err != nil {
-			return err
+			c.fallbackActivated(res.Def.Kind, res.Def.Name, SourcePull)
+			return c.loadFallback(ctx, res, err)
 		}
-
-		apiURL := fmt.Sprintf("%s/api/v1/resource/%s/%s/secret",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-		)
-
-		secretBytes, err := c.devLoadOrFetch(apiURL, cachePath, opts.SECRETS)
+	case opts.DEV:
+		raw, err = c.devResource(ctx, res)
 		if err != nil {
-			return err
-		}
-
-		if err := c.storeRawResource(secretBytes, res.Def.Kind, res.Def.Name); err != nil {
-			return err
+			return raw, err
 		}
+	default:
+		return raw, nil
+	}
 
-		c.hasWatchableResource = true
-		cacheKey := fmt.Sprintf("dev_%s_%s_secret", c.opts.Connection.Namespace, c.opts.Connection.App)
-		watcherFileNameResourceMap[cacheKey] = watcherInfo{kind: opts.SECRETS, path: cachePath, isDev: true}
-		cacheDir := filepath.Dir(cachePath)
-		c.watcher.Add(cacheDir)
-		devLogWatching(opts.SECRETS, cacheDir)
+	return raw, c.applyResource(raw, msg)
+}
 
-		return nil
+// loadFallback loads the resource from fallback after the primary source failed
+// with err, when fallback fails too both failures are reported
+func (c *Consumer[C, S]) loadFallback(ctx context.Context, res *opts.ResourceOption, err error) (rawResource, error) {
+	raw, fallbackErr := c.fetchFallback(ctx, res.Def.Kind, res.Def.Name)
+	if fallbackErr != nil {
+		return raw, withFallback(err, fallbackErr)
 	}
-	return nil
+	return raw, nil
 }
 
-func (c *Consumer[C, S]) manageMisc(res *opts.ResourceOption) error {
-	switch res.FetchDef.Fetch {
-	case opts.VOLUME:
-		// check if file is present in the path
-		resourcePath := fmt.Sprintf("%s/_%s", res.Def.Path, res.Def.Name)
-		miscBytes, err := os.ReadFile(resourcePath)
-		if err == nil {
-			if err := c.storeRawResource(miscBytes, res.Def.Kind, res.Def.Name); err != nil {
-				return err
-			}
+// readVolume reads the resource from its volume mounted path
+func (c *Consumer[C, S]) readVolume(res *opts.ResourceOption) (rawResource, error) {
+	started := time.Now()
+	path := volumePath(res)
+	resBytes, err := os.ReadFile(path)
+	if err != nil {
+		return rawResource{}, c.fetchFailed(started, &ResourceError{
+			Kind:   res.Def.Kind,
+			Name:   res.Def.Name,
+			Source: SourceVolume,
+			Stage:  StageFetch,
+			URL:    path,
+			Err:    err,
+		})
+	}
 
-			// add watcher details
-			c.hasWatchableResource = true
-			watcherFileNameResourceMap["_"+res.Def.Name] = watcherInfo{kind: opts.MISC, path: resourcePath, name: res.Def.Name}
-			c.watcher.Add(res.Def.Path)
+	return rawResource{
+		kind:    res.Def.Kind,
+		name:    res.Def.Name,
+		source:  SourceVolume,
+		version: resourceVersion(nil, resBytes),
+		started: started,
+		data:    resBytes,
+		url:     path,
+	}, nil
+}
 
-			return nil
-		}
+// pullResource pulls the latest version of the resource from Sailor
+func (c *Consumer[C, S]) pullResource(ctx context.Context, res *opts.ResourceOption) (rawResource, error) {
+	started := time.Now()
+	url := c.resourceURL(res)
+	failed := func(stage Stage, statusCode int, err error) error {
+		return c.fetchFailed(started, &ResourceError{
+			Kind:       res.Def.Kind,
+			Name:       res.Def.Name,
+			Source:     SourcePull,
+			Stage:      stage,
+			URL:        redactURL(url),
+			StatusCode: statusCode,
+			Err:        err,
+		})
+	}
 
-		if err := c.fetchFallback(res.Def.Kind, res.Def.Name); err != nil {
-			return err
-		}
+	resp, err := c.doGet(ctx, url)
+	if err != nil {
+		return rawResource{}, failed(StageFetch, 0, err)
+	}
+	defer resp.Body.Close()
 
-		return nil
-	case opts.PULL:
-		// we will pull for the latest config with version
-		url := fmt.Sprintf("%s/api/v1/resource/%s/%s/misc/%s",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-			res.Def.Name,
-		)
-
-		resp, err := c.doGet(url)
-		if err == nil {
-			if resp.StatusCode != http.StatusOK {
-				// goto fallback
-				goto PULL_MISC_FALLBACK
-			}
+	if resp.StatusCode != http.StatusOK {
+		return rawResource{}, failed(StageStatus, resp.StatusCode, c.pinnedStatusError(res, resp.StatusCode))
+	}
+	if err := servedPinnedVersion(res, resp.Header); err != nil {
+		return rawResource{}, failed(StageVersion, resp.StatusCode, err)
+	}
 
-			miscBytes, err := io.ReadAll(resp.Body)
-			defer resp.Body.Close()
-			if err != nil {
-				// goto fallback
-				goto PULL_MISC_FALLBACK
-			}
+	resBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return rawResource{}, failed(StageFetch, resp.StatusCode, err)
+	}
+	if err := c.verifyPayload(resBytes, resp.Header.Get(signing.HeaderPayloadSignature)); err != nil {
+		return rawResource{}, failed(StageSignature, resp.StatusCode, err)
+	}
 
-			if err := c.storeRawResource(miscBytes, res.Def.Kind, res.Def.Name); err != nil {
-				return err
-			}
+	return rawResource{
+		kind:       res.Def.Kind,
+		name:       res.Def.Name,
+		source:     SourcePull,
+		statusCode: resp.StatusCode,
+		version:    pinnedResourceVersion(res, resp.Header, resBytes),
+		started:    started,
+		data:       resBytes,
+		url:        url,
+		endpoint:   c.endpoints.endpointFor(resp.Request.URL),
+	}, nil
+}
 
-			// time to check if we want to pull the resource in background thread
-			if !res.FetchDef.Once {
-				go c.keepPullingResource(res)
-			}
+// devResource returns the DEV resource from the local cache, fetching it from
+// Sailor the first time
+func (c *Consumer[C, S]) devResource(ctx context.Context, res *opts.ResourceOption) (rawResource, error) {
+	started := time.Now()
+	url := c.resourceURL(res)
+	cachePath, err := devCachePath(c.opts.Connection, res)
+	if err != nil {
+		return rawResource{}, c.fetchFailed(started, &ResourceError{
+			Kind:   res.Def.Kind,
+			Name:   res.Def.Name,
+			Source: SourceDev,
+			Stage:  StageFetch,
+			Err:    err,
+		})
+	}
 
-			return nil
+	resBytes, statusCode, err := c.devLoadOrFetch(ctx, res, url, cachePath, isRefresh(ctx))
+	if err != nil {
+		stage := StageFetch
+		switch {
+		case errors.Is(err, ErrPayloadUnsigned), errors.Is(err, ErrPayloadBadSignature):
+			stage = StageSignature
+		case errors.Is(err, ErrVersionMismatch):
+			stage = StageVersion
+		case statusCode != 0 && statusCode != http.StatusOK:
+			stage = StageStatus
 		}
+		return rawResource{}, c.fetchFailed(started, &ResourceError{
+			Kind:       res.Def.Kind,
+			Name:       res.Def.Name,
+			Source:     SourceDev,
+			Stage:      stage,
+			URL:        redactURL(url),
+			StatusCode: statusCode,
+			Err:        err,
+		})
+	}
 
-	PULL_MISC_FALLBACK:
-		if err := c.fetchFallback(res.Def.Kind, res.Def.Name); err != nil {
-			return err
-		}
+	return rawResource{
+		kind:    res.Def.Kind,
+		name:    res.Def.Name,
+		source:  SourceDev,
+		version: resourceVersion(nil, resBytes),
+		started: started,
+		data:    resBytes,
+		url:     url,
+	}, nil
+}
 
-		return nil
-	case opts.DEV:
-		cachePath, err := devCachePath(c.opts.Connection, opts.MISC)
-		if err != nil {
-			return err
-		}
+func (c *Consumer[C, S]) fetchFallback(ctx context.Context, forKind opts.ResourceKind, resName string) (rawResource, error) {
+	started := time.Now()
+	fallbackBaseURL := c.fallbackBaseURL()
+	if fallbackBaseURL == "" {
+		return rawResource{}, c.fetchFailed(started, &ResourceError{
+			Kind:   forKind,
+			Name:   resName,
+			Source: SourceFallback,
+			Stage:  StageFetch,
+			Err:    ErrFetchFallbackFailed,
+		})
+	}
 
-		apiURL := fmt.Sprintf("%s/api/v1/resource/%s/%s/misc/%s",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-			res.Def.Name,
-		)
+	url := fmt.Sprintf("%s/%s-%s.sailor.fall", fallbackBaseURL, c.opts.Connection.App, forKind)
+	failed := func(stage Stage, statusCode int, err error) error {
+		return c.fetchFailed(started, &ResourceError{
+			Kind:       forKind,
+			Name:       resName,
+			Source:     SourceFallback,
+			Stage:      stage,
+			URL:        redactURL(url),
+			StatusCode: statusCode,
+			Err:        err,
+		})
+	}
 
-		miscBytes, err := c.devLoadOrFetch(apiURL, cachePath, opts.MISC)
-		if err != nil {
-			return err
-		}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return rawResource{}, failed(StageFetch, 0, err)
+	}
+	resp, err := c.sailorClient.Do(req)
+	if err != nil {
+		return rawResource{}, failed(StageFetch, 0, err)
+	}
+	defer resp.Body.Close()
 
-		if err := c.storeRawResource(miscBytes, res.Def.Kind, res.Def.Name); err != nil {
-			return err
-		}
+	if resp.StatusCode != http.StatusOK {
+		return rawResource{}, failed(StageStatus, resp.StatusCode, fmt.Errorf("%w: status %d", ErrFetchFallbackFailed, resp.StatusCode))
+	}
 
-		c.hasWatchableResource = true
-		cacheKey := fmt.Sprintf("dev_%s_%s_misc_%s", c.opts.Connection.Namespace, c.opts.Connection.App, res.Def.Name)
-		watcherFileNameResourceMap[cacheKey] = watcherInfo{kind: opts.MISC, path: cachePath, name: res.Def.Name, isDev: true}
-		cacheDir := filepath.Dir(cachePath)
-		c.watcher.Add(cacheDir)
-		devLogWatching(opts.MISC, cacheDir)
+	resBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return rawResource{}, failed(StageFetch, resp.StatusCode, err)
+	}
 
-		return nil
+	sig, err := c.fallbackSignature(ctx, url, resp.Header)
+	if err != nil {
+		return rawResource{}, failed(StageFetch, resp.StatusCode, err)
 	}
-	return nil
+	if err := c.verifyPayload(resBytes, sig); err != nil {
+		return rawResource{}, failed(StageSignature, resp.StatusCode, err)
+	}
+	if resBytes, err = c.openPayload(resBytes); err != nil {
+		return rawResource{}, failed(StageDecrypt, resp.StatusCode, err)
+	}
+
+	raw := rawResource{
+		kind:       forKind,
+		name:       resName,
+		source:     SourceFallback,
+		statusCode: resp.StatusCode,
+		version:    resourceVersion(nil, resBytes),
+		started:    started,
+		data:       resBytes,
+		url:        url,
+	}
+	if err = c.applyResource(raw, "sailor resource loaded"); err != nil {
+		return raw, err
+	}
+
+	return raw, nil
 }
 
-func (c *Consumer[C, S]) fetchFallback(forKind opts.ResourceKind, resName string) error {
-	fallbackBaseURL := os.Getenv(ENV_SAILOR_FALLBACK_BASE_URL)
-	if fallbackBaseURL != "" {
-		url := fmt.Sprintf("%s/%s-%s.sailor.fall", fallbackBaseURL, c.opts.Connection.App, forKind)
-		resp, err := c.sailorClient.Get(url)
-		if err != nil {
-			return err
-		}
+// pullInterval is how often the resource is pulled, defaultPullInterval when it
+// has no PullInterval
+func pullInterval(res *opts.ResourceOption) time.Duration {
+	if res.FetchDef.PullInterval <= 0 {
+		return defaultPullInterval
+	}
+	return res.FetchDef.PullInterval
+}
 
-		resBytes, err := io.ReadAll(resp.Body)
-		defer resp.Body.Close()
+// keepPullingResource pulls the resource every PullInterval until the consumer
+// is closed
+func (c *Consumer[C, S]) keepPullingResource(res *opts.ResourceOption) {
+	interval := pullInterval(res)
+	for c.sleep(interval) {
+		raw, err := c.pullResource(c.ctx, res)
 		if err != nil {
-			return err
+			continue
 		}
 
-		if err = c.storeRawResource(resBytes, forKind, resName); err != nil {
-			return err
+		// a pinned version in use is not replaced, pulling only tells that
+		// Sailor still serves it
+		if res.Def.Version != "" {
+			current := c.currentState(res.Def.Kind, res.Def.Name)
+			if current.loaded && current.source != SourceFallback && current.version == raw.version {
+				c.resourceConfirmed(raw)
+				continue
+			}
 		}
 
-		return nil
+		c.applyResource(raw, "sailor resource reloaded")
 	}
-
-	return ErrFetchFallbackFailed
 }
 
-func (c *Consumer[C, S]) keepPullingResource(res *opts.ResourceOption) {
-	var url string
-	switch res.Def.Kind {
-	case opts.CONFIGS:
-		url = fmt.Sprintf("%s/api/v1/resource/%s/%s/config",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-		)
-	case opts.SECRETS:
-		url = fmt.Sprintf("%s/api/v1/resource/%s/%s/secret",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-		)
-	case opts.MISC:
-		url = fmt.Sprintf("%s/api/v1/resource/%s/%s/misc/%s",
-			c.opts.Connection.Addr,
-			c.opts.Connection.Namespace,
-			c.opts.Connection.App,
-			res.Def.Name,
-		)
-	}
-
-	resp, err := c.doGet(url)
-	if err == nil {
-		if resp.StatusCode != http.StatusOK {
-			time.Sleep(res.FetchDef.PullInterval)
-			c.keepPullingResource(res)
-			return
-		}
+// resourceURL is the Sailor API endpoint serving the resource
+func (c *Consumer[C, S]) resourceURL(res *opts.ResourceOption) string {
+	resURL := fmt.Sprintf("%s/api/v1/resource/%s/%s",
+		c.opts.Connection.Addr,
+		c.opts.Connection.Namespace,
+		c.opts.Connection.App,
+	)
+
+	if res.Def.Kind == opts.MISC {
+		resURL = fmt.Sprintf("%s/misc/%s", resURL, res.Def.Name)
+	} else {
+		resURL = fmt.Sprintf("%s/%s", resURL, res.Def.Kind)
+	}
 
-		resBytes, err := io.ReadAll(resp.Body)
-		defer resp.Body.Close()
-		if err != nil {
-			time.Sleep(res.FetchDef.PullInterval)
-			c.keepPullingResource(res)
-			return
-		}
+	if res.Def.Version != "" {
+		resURL += "?version=" + url.QueryEscape(res.Def.Version)
+	}
+	return resURL
+}
 
-		if err = c.storeRawResource(resBytes, res.Def.Kind, res.Def.Name); err != nil {
-			time.Sleep(res.FetchDef.PullInterval)
-			c.keepPullingResource(res)
-			return
-		}
+// volumeFileName is the name of the file the resource is mounted as, _config
+// and _secret for configs and secrets, _<name> for misc resources
+func volumeFileName(res *opts.ResourceOption) string {
+	if res.Def.Kind == opts.MISC {
+		return "_" + res.Def.Name
 	}
 
-	time.Sleep(res.FetchDef.PullInterval)
-	c.keepPullingResource(res)
+	return "_" + string(res.Def.Kind)
+}
+
+func volumePath(res *opts.ResourceOption) string {
+	return fmt.Sprintf("%s/%s", res.Def.Path, volumeFileName(res))
 }
 
-func (c *Consumer[C, S]) storeRawResource(resBytes []byte, forKind opts.ResourceKind, resourceName string) error {
+// decodeRawResource turns the payload of a resource into the value stored for
+// its kind: *C for configs, *S for secrets and the bytes themselves for misc
+func (c *Consumer[C, S]) decodeRawResource(resBytes []byte, forKind opts.ResourceKind) (any, error) {
 	switch forKind {
 	case opts.CONFIGS:
 		var config C
 		if err := json.Unmarshal(resBytes, &config); err != nil {
-			// TODO :: log here!
-			return err
+			return nil, err
 		}
-
-		c.configs.Store(&config)
+		return &config, nil
 	case opts.SECRETS:
-		var encSecrets map[string]vault.SecretRecord
-		if err := json.Unmarshal(resBytes, &encSecrets); err != nil {
-			return err
-		}
-
-		kek, err := vault.DeriveKEK(c.opts.Connection.SecretKey, []byte(c.opts.Connection.AccessKey))
-		if err != nil {
-			return err
-		}
-
-		var interimSecrets = make(map[string]string, len(encSecrets))
-		for k, ev := range encSecrets {
-			dek, err := vault.DecryptDEK(ev.EncryptedDEK, kek)
-			if err != nil {
-				return err
-			}
-			v, err := vault.DecryptWithDEK(ev.EncryptedSecret, dek)
-			if err != nil {
-				return err
-			}
-
-			interimSecrets[k] = v
-		}
-
-		b, err := json.Marshal(&interimSecrets)
-		if err != nil {
-			return err
-		}
+		return decodeSecrets[S](resBytes, c.secretEncoding, c.opts.Connection)
+	}
 
-		var secrets S
-		if err := json.Unmarshal(b, &secrets); err != nil {
-			return err
-		}
+	return resBytes, nil
+}
 
-		c.secrets.Store(&secrets)
+// storeDecoded makes the value returned by decodeRawResource the one in use
+func (c *Consumer[C, S]) storeDecoded(value any, forKind opts.ResourceKind, resourceName string) {
+	switch forKind {
+	case opts.CONFIGS:
+		c.configs.Store(value.(*C))
+	case opts.SECRETS:
+		c.secrets.Store(value.(*S))
 	case opts.MISC:
+		c.miscMu.Lock()
+		defer c.miscMu.Unlock()
 		miscCopy := maps.Clone(*c.misc.Load())
-		miscCopy[resourceName] = resBytes
+		miscCopy[resourceName] = value.([]byte)
 		c.misc.Store(&miscCopy)
 	}
+}
 
-	return nil
+// Metrics returns the Metrics the consumer reports to, this is the registry
+// created by NewConsumer unless one was passed in InitOption.Metrics
+func (c *Consumer[C, S]) Metrics() metrics.Metrics {
+	return c.metrics
 }
 
 // Get returns the current configuration
 func (c *Consumer[C, S]) Get() (C, error) {
 	configPtr := c.configs.Load()
+	if configPtr == nil && c.waitFirstRead(opts.CONFIGS, "", func() bool { return c.configs.Load() != nil }) {
+		configPtr = c.configs.Load()
+	}
 	if configPtr == nil {
 		var zero C
 		return zero, ErrConfigsNotLoaded
@@ -691,6 +898,9 @@ func (c *Consumer[C, S]) Get() (C, error) {
 // Get returns the current secrets
 func (c *Consumer[C, S]) GetSecret() (S, error) {
 	secretPtr := c.secrets.Load()
+	if secretPtr == nil && c.waitFirstRead(opts.SECRETS, "", func() bool { return c.secrets.Load() != nil }) {
+		secretPtr = c.secrets.Load()
+	}
 	if secretPtr == nil {
 		var zero S
 		return zero, ErrSecretsNotLoaded
@@ -701,21 +911,38 @@ func (c *Consumer[C, S]) GetSecret() (S, error) {
 
 // Get misc resource bytes by name
 func (c *Consumer[C, S]) GetMisc(name string) ([]byte, error) {
-	miscMap := *c.misc.Load()
-	if _, ok := miscMap[name]; !ok {
+	hasMisc := func() bool {
+		miscPtr := c.misc.Load()
+		if miscPtr == nil {
+			return false
+		}
+		_, ok := (*miscPtr)[name]
+		return ok
+	}
+
+	if !hasMisc() && !c.waitFirstRead(opts.MISC, name, hasMisc) {
 		return []byte{}, ErrMiscNotLoaded
 	}
 
-	return miscMap[name], nil
+	return (*c.misc.Load())[name], nil
 }
 
 func parseURI(uri string) (*opts.ConnectionOption, error) {
-	u, err := url.Parse(uri)
+	first, extraHosts := splitURIHosts(uri)
+	u, err := url.Parse(first)
 	if err != nil {
 		return nil, err
 	}
 
-	if u.Scheme != "sailor" {
+	// sailor:// is reached over https, sailor+https:// and sailor+http:// tell
+	// how to reach the host
+	var addr string
+	switch u.Scheme {
+	case "sailor":
+		addr = "https://" + u.Host
+	case "sailor+https", "sailor+http":
+		addr = strings.TrimPrefix(u.Scheme, "sailor+") + "://" + u.Host
+	default:
 		return nil, ErrInvalidURIPrefix
 	}
 
@@ -737,60 +964,151 @@ func parseURI(uri string) (*opts.ConnectionOption, error) {
 
 	var opt = opts.ConnectionOption{
 		URI:       uri,
-		Addr:      u.Host,
+		Addr:      addr,
 		Namespace: components[0],
 		App:       components[1],
 		AccessKey: accessKey,
 		SecretKey: secretKey,
 	}
 
+	for _, host := range extraHosts {
+		opt.Addrs = append(opt.Addrs, strings.TrimSuffix(addr, u.Host)+host)
+	}
+
+	if err := applyURIQuery(&opt, u.Query()); err != nil {
+		return nil, err
+	}
+
 	return &opt, nil
 }
 
 // devLoadOrFetch returns resource bytes from the cache file if it already exists,
 // otherwise fetches from the API, writes the result to cache, and returns it.
-func (c *Consumer[C, S]) devLoadOrFetch(apiURL, cachePath string, kind opts.ResourceKind) ([]byte, error) {
-	if data, err := os.ReadFile(cachePath); err == nil {
-		devLogCacheHit(kind, cachePath)
-		return data, nil
+// Fetched payloads are verified against InitOption.Signature before being
+// cached, the cache only holds trusted payloads. force skips the cache, this is
+// used by Refresh to pick up pushed changes. The HTTP status is returned when
+// the API was called, zero for a cache hit.
+func (c *Consumer[C, S]) devLoadOrFetch(ctx context.Context, res *opts.ResourceOption, apiURL, cachePath string, force bool) ([]byte, int, error) {
+	started := time.Now()
+	if !force {
+		if data, ok := c.readDevCache(cachePath); ok {
+			c.devLogCacheHit(res, cachePath, data, started)
+			return data, 0, nil
+		}
 	}
 
-	devLogFetching(kind, apiURL)
+	c.devLogFetching(res, apiURL)
 
-	resp, err := c.doGet(apiURL)
+	resp, err := c.doGet(ctx, apiURL)
 	if err != nil {
-		return nil, err
+		// Sailor is known to be failing, the cached copy beats no value
+		if errors.Is(err, ErrCircuitOpen) {
+			if data, ok := c.readDevCache(cachePath); ok {
+				c.devLogCacheHit(res, cachePath, data, started)
+				return data, 0, nil
+			}
+		}
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, ErrFetchFallbackFailed
+		if err := c.statusError(resp.StatusCode); errors.Is(err, ErrTokenExpired) {
+			return nil, resp.StatusCode, err
+		}
+		if err := c.pinnedStatusError(res, resp.StatusCode); errors.Is(err, ErrVersionUnavailable) {
+			return nil, resp.StatusCode, err
+		}
+		return nil, resp.StatusCode, fmt.Errorf("%w: sailor responded with status %d", ErrFetchFallbackFailed, resp.StatusCode)
+	}
+	if err := servedPinnedVersion(res, resp.Header); err != nil {
+		return nil, resp.StatusCode, err
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, resp.StatusCode, err
+	}
+	if err := c.verifyPayload(data, resp.Header.Get(signing.HeaderPayloadSignature)); err != nil {
+		return nil, resp.StatusCode, err
 	}
 
-	if err := os.WriteFile(cachePath, data, 0644); err != nil {
-		return nil, err
+	cached, err := c.sealPayload(data)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if err := os.WriteFile(cachePath, cached, 0644); err != nil {
+		return nil, resp.StatusCode, err
 	}
 
-	devLogCached(kind, cachePath)
-	return data, nil
+	c.devLogCached(res, cachePath, data, started)
+	return data, resp.StatusCode, nil
 }
 
-func (c *Consumer[C, S]) doGet(url string) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+func (c *Consumer[C, S]) doGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	if c.opts.Connection != nil && c.opts.Connection.Token != "" {
-		req.Header.Set("x-token", c.opts.Connection.Token)
+	return c.do(req)
+}
+
+// do sends a request to the Sailor API, every call to Sailor goes through here.
+// Requests fail over across the replicas of the connection and fail right away
+// while the circuit breaker is open. When the token from ~/.sailor/config is
+// rejected the file is read again and the request retried once with the token
+// refreshed by `sailor login`.
+func (c *Consumer[C, S]) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.sendThroughCircuit(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !c.opts.UseSailorConfig {
+		return resp, err
+	}
+
+	if !c.reloadLocalToken() {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	// the retry is signed again with a nonce of its own, the one of the
+	// rejected request would be taken for a replay
+	for _, header := range []string{"Authorization", signing.HeaderTimestamp, signing.HeaderNonce, signing.HeaderContentSHA256} {
+		retry.Header.Del(header)
+	}
+	resp.Body.Close()
+	return c.roundTrip(retry)
+}
+
+// send authenticates the request and sends it, requests are signed when the
+// connection has an AccessKey and a SecretKey
+func (c *Consumer[C, S]) send(req *http.Request) (*http.Response, error) {
+	if conn := c.opts.Connection; conn != nil {
+		if token := c.currentToken(); token != "" {
+			req.Header.Set("x-token", token)
+		}
+		if conn.AccessKey != "" && conn.SecretKey != "" {
+			if err := signing.Sign(req, conn.AccessKey, conn.SecretKey, time.Now()); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return c.sailorClient.Do(req)
 }
 
-func devCachePath(conn *opts.ConnectionOption, kind opts.ResourceKind) (string, error) {
+// statusError explains a response other than 200 from Sailor
+func (c *Consumer[C, S]) statusError(statusCode int) error {
+	if statusCode == http.StatusUnauthorized && c.opts.UseSailorConfig {
+		return ErrTokenExpired
+	}
+	return fmt.Errorf("sailor responded with status %d", statusCode)
+}
+
+// devCachePath is where the DEV resource is cached, a pinned version is cached
+// on its own
+func devCachePath(conn *opts.ConnectionOption, res *opts.ResourceOption) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -799,6 +1117,9 @@ func devCachePath(conn *opts.ConnectionOption, kind opts.ResourceKind) (string,
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", err
 	}
-	name := fmt.Sprintf("%s-%s-%s-%s.json", conn.Namespace, conn.App, conn.Env, kind)
+	name := fmt.Sprintf("%s-%s-%s-%s.json", conn.Namespace, conn.App, conn.Env, res.Def.Kind)
+	if res.Def.Version != "" {
+		name = fmt.Sprintf("%s-%s-%s-%s@%s.json", conn.Namespace, conn.App, conn.Env, res.Def.Kind, filepath.Base(res.Def.Version))
+	}
 	return filepath.Join(dir, name), nil
 }
@@ -113,6 +113,7 @@ func TestVolumeConfigFileNotPresent(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	// this should error because there is no file named _config
 	// inside the testFolder, it should try calling fallback and
@@ -158,6 +159,7 @@ func TestVolumeConfigWrongType(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	// this should throw an error because the json parsing failed
 	if consumer.Start() == nil {
@@ -201,6 +203,7 @@ func TestVolumeConfigCorrectData(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	// this should not return an error because now the data is of correct
 	// type
@@ -228,8 +231,8 @@ func TestParseURI(t *testing.T) {
 		return
 	}
 
-	if opts.Addr != "localhost" {
-		t.Errorf("the address of the URI must be %s but got %s", "localhost", opts.Addr)
+	if opts.Addr != "https://localhost" {
+		t.Errorf("the address of the URI must be %s but got %s", "https://localhost", opts.Addr)
 	}
 
 	if opts.AccessKey != "a" {
@@ -279,6 +282,7 @@ func TestNewConsumerWithURIFromEnv(t *testing.T) {
 		t.Errorf("NewConsumer should not error with valid URI from env, got: %v", err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if consumer == nil {
 		t.Error("NewConsumer should return a consumer")
@@ -291,8 +295,8 @@ func TestNewConsumerWithURIFromEnv(t *testing.T) {
 	}
 
 	// Verify parsed connection options
-	if consumer.opts.Connection.Addr != "localhost:7766" {
-		t.Errorf("expected Addr to be 'localhost:7766', got '%s'", consumer.opts.Connection.Addr)
+	if consumer.opts.Connection.Addr != "https://localhost:7766" {
+		t.Errorf("expected Addr to be 'https://localhost:7766', got '%s'", consumer.opts.Connection.Addr)
 	}
 
 	if consumer.opts.Connection.Namespace != "testns" {
@@ -342,6 +346,7 @@ func TestNewConsumerWithURIFromInitOpts(t *testing.T) {
 		t.Errorf("NewConsumer should not error with valid URI from initOpts, got: %v", err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if consumer == nil {
 		t.Error("NewConsumer should return a consumer")
@@ -354,8 +359,8 @@ func TestNewConsumerWithURIFromInitOpts(t *testing.T) {
 	}
 
 	// Verify parsed connection options
-	if consumer.opts.Connection.Addr != "example.com:8080" {
-		t.Errorf("expected Addr to be 'example.com:8080', got '%s'", consumer.opts.Connection.Addr)
+	if consumer.opts.Connection.Addr != "https://example.com:8080" {
+		t.Errorf("expected Addr to be 'https://example.com:8080', got '%s'", consumer.opts.Connection.Addr)
 	}
 
 	if consumer.opts.Connection.Namespace != "myns" {
@@ -551,6 +556,7 @@ func TestNewConsumerWithURIEnvTakesPrecedence(t *testing.T) {
 		t.Errorf("NewConsumer should not error, got: %v", err)
 		return
 	}
+	t.Cleanup(func() { consumer.Close() })
 
 	if consumer == nil {
 		t.Error("NewConsumer should return a consumer")
@@ -564,8 +570,8 @@ func TestNewConsumerWithURIEnvTakesPrecedence(t *testing.T) {
 
 	// Verify that env URI takes precedence over initOpts URI
 	// The env URI should be parsed, not the optsURI
-	if consumer.opts.Connection.Addr != "envhost:9999" {
-		t.Errorf("expected Addr to be 'envhost:9999' (from env), got '%s'", consumer.opts.Connection.Addr)
+	if consumer.opts.Connection.Addr != "https://envhost:9999" {
+		t.Errorf("expected Addr to be 'https://envhost:9999' (from env), got '%s'", consumer.opts.Connection.Addr)
 	}
 
 	if consumer.opts.Connection.Namespace != "envns" {
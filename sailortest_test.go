@@ -0,0 +1,82 @@
+package sailor
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestSailortestObservesUpdates(t *testing.T) {
+	type DummyConfig struct {
+		App string `json:"app"`
+	}
+	type DummySecret struct {
+		Password string `json:"password"`
+	}
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", DummyConfig{App: "first"})
+	server.SetSecrets("test", "test", "ak", "sk", map[string]string{"password": "first"})
+
+	consumer, err := NewConsumer[DummyConfig, DummySecret](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 5 * time.Millisecond},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.SECRETS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 5 * time.Millisecond},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetConfig("test", "test", DummyConfig{App: "second"})
+	server.SetSecrets("test", "test", "ak", "sk", map[string]string{"password": "second"})
+
+	sailortest.WaitForConfig(t, consumer, time.Second, func(c DummyConfig) bool { return c.App == "second" })
+	sailortest.WaitForSecret(t, consumer, time.Second, func(s DummySecret) bool { return s.Password == "second" })
+}
+
+func TestSailortestFallback(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetStatus(http.StatusServiceUnavailable)
+	server.SetFallback("test", opts.MISC, []byte("from fallback"))
+
+	os.Setenv(ENV_SAILOR_FALLBACK_BASE_URL, server.FallbackURL())
+	defer os.Unsetenv(ENV_SAILOR_FALLBACK_BASE_URL)
+
+	consumer, err := NewConsumer[any, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:             opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef:        opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+				FallbackEnabled: true,
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.WaitForMisc(t, consumer, "certs", time.Second, func(b []byte) bool { return string(b) == "from fallback" })
+}
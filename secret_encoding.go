@@ -0,0 +1,102 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor/pkg/vault"
+)
+
+// decodeSecrets binds the raw secret resource into S according to the encoding
+// defined on the SECRETS ResourceOption.
+func decodeSecrets[S any](resBytes []byte, encoding opts.SecretEncoding, conn *opts.ConnectionOption) (*S, error) {
+	var secrets S
+	switch encoding {
+	case opts.PLAINTEXT:
+		if err := json.Unmarshal(resBytes, &secrets); err != nil {
+			return nil, err
+		}
+
+		return &secrets, nil
+	case opts.BASE64:
+		var encValues map[string]string
+		if err := json.Unmarshal(resBytes, &encValues); err != nil {
+			return nil, err
+		}
+
+		var interimSecrets = make(map[string]string, len(encValues))
+		for k, ev := range encValues {
+			v, err := base64.StdEncoding.DecodeString(ev)
+			if err != nil {
+				return nil, ErrSecretValueNotBase64
+			}
+
+			interimSecrets[k] = string(v)
+		}
+
+		return rebindSecrets[S](interimSecrets)
+	case opts.VAULT:
+		if conn == nil || conn.AccessKey == "" || conn.SecretKey == "" {
+			return nil, &decryptError{ErrSecretsNoCredentials}
+		}
+
+		var encSecrets map[string]vault.SecretRecord
+		if err := json.Unmarshal(resBytes, &encSecrets); err != nil {
+			return nil, err
+		}
+
+		kek, err := vault.DeriveKEK(conn.SecretKey, []byte(conn.AccessKey))
+		if err != nil {
+			return nil, &decryptError{err}
+		}
+
+		var interimSecrets = make(map[string]string, len(encSecrets))
+		for k, ev := range encSecrets {
+			dek, err := vault.DecryptDEK(ev.EncryptedDEK, kek)
+			if err != nil {
+				return nil, &decryptError{err}
+			}
+			v, err := vault.DecryptWithDEK(ev.EncryptedSecret, dek)
+			if err != nil {
+				return nil, &decryptError{err}
+			}
+
+			interimSecrets[k] = v
+		}
+
+		return rebindSecrets[S](interimSecrets)
+	}
+
+	return nil, ErrUnknownSecretEncoding
+}
+
+// rebindSecrets converts decoded key/value secrets into the caller's type S
+func rebindSecrets[S any](values map[string]string) (*S, error) {
+	b, err := json.Marshal(&values)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets S
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, err
+	}
+
+	return &secrets, nil
+}
@@ -0,0 +1,127 @@
+package sailor
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+func TestVolumeSecretsPlaintext(t *testing.T) {
+	type DummySecret struct {
+		Password string `json:"password"`
+	}
+
+	password := "supersecret"
+	createTestFile(map[string]string{"password": password}, "_secret")
+	defer removeTestFile("_secret")
+
+	consumer, err := NewConsumer[any, DummySecret](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def: opts.ResourceDefinition{
+					Kind:           opts.SECRETS,
+					Path:           testFolder,
+					SecretEncoding: opts.PLAINTEXT,
+				},
+				FetchDef: opts.FetchDefinition{
+					Fetch: opts.VOLUME,
+				},
+			},
+		},
+		// no AccessKey/SecretKey, plaintext secrets do not need them
+		Connection: &opts.ConnectionOption{
+			Namespace: "test",
+			App:       "test",
+		},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err = consumer.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	secret, err := consumer.GetSecret()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if secret.Password != password {
+		t.Errorf("required %s got %s", password, secret.Password)
+	}
+}
+
+func TestVolumeSecretsBase64(t *testing.T) {
+	type DummySecret struct {
+		Password string `json:"password"`
+	}
+
+	password := "supersecret"
+	createTestFile(map[string]string{
+		"password": base64.StdEncoding.EncodeToString([]byte(password)),
+	}, "_secret")
+	defer removeTestFile("_secret")
+
+	consumer, err := NewConsumer[any, DummySecret](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def: opts.ResourceDefinition{
+					Kind:           opts.SECRETS,
+					Path:           testFolder,
+					SecretEncoding: opts.BASE64,
+				},
+				FetchDef: opts.FetchDefinition{
+					Fetch: opts.VOLUME,
+				},
+			},
+		},
+		Connection: &opts.ConnectionOption{
+			Namespace: "test",
+			App:       "test",
+		},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err = consumer.Start(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	secret, err := consumer.GetSecret()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if secret.Password != password {
+		t.Errorf("required %s got %s", password, secret.Password)
+	}
+}
+
+func TestDecodeSecretsErrors(t *testing.T) {
+	_, err := decodeSecrets[map[string]string]([]byte(`{"password":"%%%"}`), opts.BASE64, nil)
+	if !errors.Is(err, ErrSecretValueNotBase64) {
+		t.Errorf("expected ErrSecretValueNotBase64, got %v", err)
+	}
+
+	_, err = decodeSecrets[map[string]string]([]byte(`{}`), opts.VAULT, &opts.ConnectionOption{})
+	if !errors.Is(err, ErrSecretsNoCredentials) {
+		t.Errorf("expected ErrSecretsNoCredentials, got %v", err)
+	}
+
+	_, err = decodeSecrets[map[string]string]([]byte(`{}`), opts.SecretEncoding(42), nil)
+	if !errors.Is(err, ErrUnknownSecretEncoding) {
+		t.Errorf("expected ErrUnknownSecretEncoding, got %v", err)
+	}
+}
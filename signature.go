@@ -0,0 +1,96 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package sailor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/signing"
+)
+
+// maxSignatureFileSize bounds reading a detached signature, a base64 ed25519
+// signature is 88 bytes
+const maxSignatureFileSize = 1024
+
+// checkSignatureOption makes sure a payload can ever be accepted, an empty or
+// malformed key list would reject every payload
+func checkSignatureOption(opt *opts.SignatureOption) error {
+	if opt == nil {
+		return nil
+	}
+	if len(opt.PublicKeys) == 0 {
+		return fmt.Errorf("%w: no PublicKeys", ErrInvalidSignatureKey)
+	}
+	for i, key := range opt.PublicKeys {
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("%w: PublicKeys[%d] is %d bytes", ErrInvalidSignatureKey, i, len(key))
+		}
+	}
+	return nil
+}
+
+// verifyPayload checks the signature of a pulled or fallback payload when
+// InitOption.Signature is set, it is run before the payload is decoded
+func (c *Consumer[C, S]) verifyPayload(payload []byte, sig string) error {
+	if c.opts.Signature == nil {
+		return nil
+	}
+	if sig == "" {
+		return ErrPayloadUnsigned
+	}
+	if !signing.VerifyPayload(c.opts.Signature.PublicKeys, payload, sig) {
+		return ErrPayloadBadSignature
+	}
+	return nil
+}
+
+// fallbackSignature is the signature of the fallback file at url, taken from
+// its response header or else from the detached file next to it
+func (c *Consumer[C, S]) fallbackSignature(ctx context.Context, url string, header http.Header) (string, error) {
+	if c.opts.Signature == nil {
+		return "", nil
+	}
+	if sig := header.Get(signing.HeaderPayloadSignature); sig != "" {
+		return sig, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+signing.PayloadSignatureSuffix, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.sailorClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// a missing signature file is an unsigned payload, verifyPayload says so
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureFileSize))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
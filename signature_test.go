@@ -0,0 +1,189 @@
+package sailor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func assertSignatureError(t *testing.T, err error, target error, source Source) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatalf("expected %v, got %v", target, err)
+	}
+	var re *ResourceError
+	if !errors.As(err, &re) || re.Stage != StageSignature || re.Source != source {
+		t.Errorf("expected a %s signature ResourceError, got %v", source, err)
+	}
+}
+
+func TestSignaturePulled(t *testing.T) {
+	oldPub, oldKey, _ := ed25519.GenerateKey(nil)
+	newPub, _, _ := ed25519.GenerateKey(nil)
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	server.SignPayloads(oldKey)
+
+	// payloads signed by the old key are still accepted while rotating
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{newPub, oldPub}},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "1")
+
+	// an unsigned payload is rejected before it replaces the one in use
+	server.SignPayloads(nil)
+	server.SetConfig("test", "test", map[string]string{"release": "2"})
+	_, err := consumer.Refresh(context.Background(), opts.CONFIGS, "")
+	assertSignatureError(t, err, ErrPayloadUnsigned, SourcePull)
+	assertRelease(t, consumer, "1")
+}
+
+func TestSignaturePulledUntrustedKey(t *testing.T) {
+	trusted, _, _ := ed25519.GenerateKey(nil)
+	_, untrusted, _ := ed25519.GenerateKey(nil)
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	server.SignPayloads(untrusted)
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{trusted}},
+	})
+	assertSignatureError(t, consumer.Start(), ErrPayloadBadSignature, SourcePull)
+	if _, err := consumer.Get(); !errors.Is(err, ErrConfigsNotLoaded) {
+		t.Errorf("expected the config not to be loaded, got %v", err)
+	}
+}
+
+func TestSignatureDev(t *testing.T) {
+	trusted, key, _ := ed25519.GenerateKey(nil)
+	home := t.TempDir()
+	overrideHome(t, home)
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "1"})
+	devConfig := opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+		FetchDef: opts.FetchDefinition{Fetch: opts.DEV},
+	}
+
+	// an unsigned payload is neither used nor cached
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{devConfig},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{trusted}},
+	})
+	assertSignatureError(t, consumer.Start(), ErrPayloadUnsigned, SourceDev)
+	if matches, _ := filepath.Glob(filepath.Join(home, ".sailor", "cache", "*.json")); len(matches) != 0 {
+		t.Errorf("expected nothing to be cached, got %v", matches)
+	}
+
+	server.SignPayloads(key)
+	consumer = newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{devConfig},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{trusted}},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "1")
+}
+
+func TestSignatureFallback(t *testing.T) {
+	t.Setenv(ENV_SAILOR_FALLBACK_BASE_URL, "")
+	pub, key, _ := ed25519.GenerateKey(nil)
+
+	server := sailortest.NewServer(t)
+	server.SetStatus(503)
+	server.SetFallback("test", opts.CONFIGS, []byte(`{"release": "fallback"}`))
+	server.SignPayloads(key)
+
+	// the signature comes from the detached .sig file next to the fallback
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: fallbackConn(server),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{pub}},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "fallback")
+	if server.Requests("/fallback/test-config.sailor.fall.sig") != 1 {
+		t.Errorf("expected the detached signature to be fetched")
+	}
+
+	server.SignPayloads(nil)
+	unsigned := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{configPullOnce()},
+		Connection: fallbackConn(server),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{pub}},
+	})
+	err := unsigned.Start()
+
+	// the pull failure is reported along with the rejected fallback
+	var re *ResourceError
+	if !errors.As(err, &re) || re.Source != SourcePull || re.Fallback == nil {
+		t.Fatalf("expected the pull failure with its fallback, got %v", err)
+	}
+	assertSignatureError(t, re.Fallback, ErrPayloadUnsigned, SourceFallback)
+}
+
+func TestSignatureBulk(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+
+	server := sailortest.NewServer(t)
+	setBulkTestValues(t, server)
+	server.SignPayloads(key)
+
+	consumer := newTestConsumer[map[string]string, map[string]string](t, opts.InitOption{
+		Resources: []opts.ResourceOption{
+			configPullOnce(),
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Signature:  &opts.SignatureOption{PublicKeys: []ed25519.PublicKey{pub}},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := server.Requests("/api/v1/resource/test/test/config"); n != 0 {
+		t.Errorf("expected signed bulk resources to be used, got %d config requests", n)
+	}
+}
+
+func TestSignatureInvalidKeys(t *testing.T) {
+	server := sailortest.NewServer(t)
+
+	for name, keys := range map[string][]ed25519.PublicKey{
+		"no keys":   nil,
+		"short key": {ed25519.PublicKey("short")},
+	} {
+		_, err := NewConsumer[map[string]string, any](opts.InitOption{
+			Resources:  []opts.ResourceOption{ConfigPullDefault()},
+			Connection: server.Connection("test", "test", "ak", "sk"),
+			Signature:  &opts.SignatureOption{PublicKeys: keys},
+		})
+		if !errors.Is(err, ErrInvalidSignatureKey) {
+			t.Errorf("%s: expected ErrInvalidSignatureKey, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package sailor
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestSignedRequests(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.RequireSigning(map[string]string{"ak": "sk"})
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+	server.SetMisc("test", "test", "certs", []byte("certs"))
+
+	resources := []opts.ResourceOption{
+		{
+			Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+		},
+		{
+			Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+		},
+	}
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  resources,
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if server.Requests("/api/v1/bulk/test/test") != 1 {
+		t.Error("expected the signed bulk request to be served")
+	}
+
+	consumer = newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  resources,
+		Connection: server.Connection("test", "test", "ak", "wrong"),
+	})
+
+	var re *ResourceError
+	if err := consumer.Start(); !errors.As(err, &re) || re.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong secret key got %v", err)
+	}
+}
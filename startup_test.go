@@ -0,0 +1,150 @@
+package sailor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestStartOptionalResource(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+
+	optional := false
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, PullInterval: 5 * time.Millisecond},
+				Required: &optional,
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatalf("expected a missing optional resource not to fail start: %v", err)
+	}
+
+	if config, _ := consumer.Get(); config["app"] != "test" {
+		t.Errorf("unexpected config %v", config)
+	}
+	if _, err := consumer.GetMisc("certs"); !errors.Is(err, ErrMiscNotLoaded) {
+		t.Errorf("expected misc not to be loaded got %v", err)
+	}
+	if !consumer.Status().Ready {
+		t.Error("expected a missing optional resource not to affect readiness")
+	}
+
+	// retried in the background and then kept up to date
+	server.SetMisc("test", "test", "certs", []byte("first"))
+	sailortest.WaitForMisc(t, consumer, "certs", 3*time.Second, func(b []byte) bool { return string(b) == "first" })
+
+	server.SetMisc("test", "test", "certs", []byte("second"))
+	sailortest.WaitForMisc(t, consumer, "certs", time.Second, func(b []byte) bool { return string(b) == "second" })
+}
+
+func TestStartJoinsRequiredErrors(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+
+	optional := false
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.SECRETS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "rules"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+				Required: &optional,
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	err = consumer.Start()
+	if !errors.Is(err, ErrFetchFallbackFailed) {
+		t.Fatalf("expected fallback failure got %v", err)
+	}
+
+	var failed []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		resource, _, _ := strings.Cut(line, ": ")
+		failed = append(failed, resource)
+	}
+	if strings.Join(failed, ",") != "secret,misc certs" {
+		t.Errorf("expected secret and misc certs to fail got %q", err)
+	}
+}
+
+func TestStartLoadsConcurrently(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetBulk(false)
+	server.SetLatency(100 * time.Millisecond)
+	server.SetConfig("test", "test", map[string]string{"app": "test"})
+	server.SetMisc("test", "test", "certs", []byte("certs"))
+	server.SetMisc("test", "test", "rules", []byte("rules"))
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "rules"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	started := time.Now()
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the failed bulk attempt and then every resource at once
+	if took := time.Since(started); took > 280*time.Millisecond {
+		t.Errorf("expected resources to load concurrently, start took %s", took)
+	}
+	for _, name := range []string{"certs", "rules"} {
+		if misc, _ := consumer.GetMisc(name); string(misc) != name {
+			t.Errorf("expected %s got %s", name, misc)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// SourceStatic is the source of values pushed into a StaticConsumer
+const SourceStatic Source = "static"
+
+// Provider is what application code needs from a consumer, both *Consumer and
+// *StaticConsumer implement it so code depending on it can be unit tested
+// without a Sailor server.
+type Provider[C any, S any] interface {
+	Get() (C, error)
+	GetSecret() (S, error)
+	GetMisc(name string) ([]byte, error)
+}
+
+var (
+	_ Provider[any, any] = (*Consumer[any, any])(nil)
+	_ Provider[any, any] = (*StaticConsumer[any, any])(nil)
+)
+
+// StaticConsumer serves in-memory values through the same getters as a Consumer
+// instead of fetching them, it is meant for unit tests and offline tools.
+// Status, handlers and OnChange work the same way as for a regular Consumer.
+type StaticConsumer[C any, S any] struct {
+	// consumer holds the values, it is never started so only what makes sense
+	// for static values is exposed
+	consumer *Consumer[C, S]
+
+	// revision is bumped on every Set call and used as the resource version
+	revision atomic.Int64
+}
+
+// NewStaticConsumer returns a StaticConsumer which is already loaded with the
+// given config, secrets and misc resources. No connection, env variable or
+// Start call is needed.
+func NewStaticConsumer[C any, S any](config C, secrets S, misc map[string][]byte) *StaticConsumer[C, S] {
+	c := &Consumer[C, S]{
+		logger:  slog.New(slog.DiscardHandler),
+		metrics: metrics.NewRegistry(),
+	}
+	c.misc.Store(&map[string][]byte{})
+
+	c.opts.Resources = []opts.ResourceOption{
+		{Def: opts.ResourceDefinition{Kind: opts.CONFIGS}},
+		{Def: opts.ResourceDefinition{Kind: opts.SECRETS}},
+	}
+	names := make([]string, 0, len(misc))
+	for name := range misc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.opts.Resources = append(c.opts.Resources, opts.ResourceOption{
+			Def: opts.ResourceDefinition{Kind: opts.MISC, Name: name},
+		})
+	}
+
+	sc := &StaticConsumer[C, S]{consumer: c}
+	sc.SetConfig(config)
+	sc.SetSecret(secrets)
+	for _, name := range names {
+		sc.SetMisc(name, misc[name])
+	}
+
+	return sc
+}
+
+// Get returns the current configuration
+func (sc *StaticConsumer[C, S]) Get() (C, error) {
+	return sc.consumer.Get()
+}
+
+// GetSecret returns the current secrets
+func (sc *StaticConsumer[C, S]) GetSecret() (S, error) {
+	return sc.consumer.GetSecret()
+}
+
+// GetMisc returns the misc resource bytes by name
+func (sc *StaticConsumer[C, S]) GetMisc(name string) ([]byte, error) {
+	return sc.consumer.GetMisc(name)
+}
+
+// OnChange registers fn to be called every time a Set call replaces a value
+func (sc *StaticConsumer[C, S]) OnChange(fn func(Change)) {
+	sc.consumer.OnChange(fn)
+}
+
+// Status returns the state of every static resource
+func (sc *StaticConsumer[C, S]) Status() Status {
+	return sc.consumer.Status()
+}
+
+// HealthHandler works like Consumer.HealthHandler
+func (sc *StaticConsumer[C, S]) HealthHandler() http.Handler {
+	return sc.consumer.HealthHandler()
+}
+
+// ReadinessHandler works like Consumer.ReadinessHandler
+func (sc *StaticConsumer[C, S]) ReadinessHandler() http.Handler {
+	return sc.consumer.ReadinessHandler()
+}
+
+// DebugHandler works like Consumer.DebugHandler
+func (sc *StaticConsumer[C, S]) DebugHandler() http.Handler {
+	return sc.consumer.DebugHandler()
+}
+
+// Metrics returns the registry the static consumer reports to
+func (sc *StaticConsumer[C, S]) Metrics() metrics.Metrics {
+	return sc.consumer.Metrics()
+}
+
+// SetConfig replaces the config and notifies the OnChange listeners
+func (sc *StaticConsumer[C, S]) SetConfig(config C) {
+	sc.consumer.storeDecoded(&config, opts.CONFIGS, "")
+	sc.stored(opts.CONFIGS, "")
+}
+
+// SetSecret replaces the secrets and notifies the OnChange listeners
+func (sc *StaticConsumer[C, S]) SetSecret(secrets S) {
+	sc.consumer.storeDecoded(&secrets, opts.SECRETS, "")
+	sc.stored(opts.SECRETS, "")
+}
+
+// SetMisc replaces the misc resource with the given name and notifies the
+// OnChange listeners
+func (sc *StaticConsumer[C, S]) SetMisc(name string, b []byte) {
+	sc.consumer.storeDecoded(b, opts.MISC, name)
+	sc.stored(opts.MISC, name)
+}
+
+func (sc *StaticConsumer[C, S]) stored(kind opts.ResourceKind, name string) {
+	sc.consumer.resourceStored(rawResource{
+		kind:    kind,
+		name:    name,
+		source:  SourceStatic,
+		version: fmt.Sprintf("static-%d", sc.revision.Add(1)),
+		started: time.Now(),
+	}, "sailor resource loaded")
+}
@@ -0,0 +1,112 @@
+package sailor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+type staticTestConfig struct {
+	App string `json:"app"`
+}
+
+type staticTestSecret struct {
+	Password string `json:"password"`
+}
+
+// appName stands in for application code which only depends on a Provider
+func appName(p Provider[staticTestConfig, staticTestSecret]) string {
+	config, err := p.Get()
+	if err != nil {
+		return ""
+	}
+	return config.App
+}
+
+func TestStaticConsumer(t *testing.T) {
+	consumer := NewStaticConsumer(
+		staticTestConfig{App: "first"},
+		staticTestSecret{Password: "pw"},
+		map[string][]byte{"certs": []byte("pem")},
+	)
+
+	if name := appName(consumer); name != "first" {
+		t.Errorf("expected first got %s", name)
+	}
+
+	secret, err := consumer.GetSecret()
+	if err != nil || secret.Password != "pw" {
+		t.Errorf("unexpected secret %+v %v", secret, err)
+	}
+
+	misc, err := consumer.GetMisc("certs")
+	if err != nil || string(misc) != "pem" {
+		t.Errorf("unexpected misc %s %v", misc, err)
+	}
+
+	if status := consumer.Status(); !status.Ready || len(status.Resources) != 3 {
+		t.Errorf("expected a ready status with 3 resources got %+v", status)
+	}
+
+	var changes []Change
+	consumer.OnChange(func(c Change) { changes = append(changes, c) })
+
+	consumer.SetConfig(staticTestConfig{App: "second"})
+	if name := appName(consumer); name != "second" {
+		t.Errorf("expected second got %s", name)
+	}
+
+	if len(changes) != 1 || changes[0].Kind != opts.CONFIGS || changes[0].Source != SourceStatic ||
+		changes[0].Version == changes[0].PreviousVersion {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestStaticConsumerConcurrentSetMisc(t *testing.T) {
+	consumer := NewStaticConsumer(staticTestConfig{}, staticTestSecret{}, nil)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			consumer.SetMisc(fmt.Sprintf("misc-%d", i), []byte("v"))
+		}()
+	}
+	wg.Wait()
+
+	for i := range 50 {
+		if _, err := consumer.GetMisc(fmt.Sprintf("misc-%d", i)); err != nil {
+			t.Errorf("expected misc-%d to be kept, got %v", i, err)
+		}
+	}
+}
+
+func TestOnChangeOnlyOnNewVersion(t *testing.T) {
+	createTestFile(map[string]string{"app": "sailor"}, "_config")
+	defer removeTestFile("_config")
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{volumeConfig(0)},
+		Connection: &opts.ConnectionOption{Namespace: "test", App: "test"},
+	})
+
+	var changes []Change
+	consumer.OnChange(func(c Change) { changes = append(changes, c) })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := rawResource{kind: opts.CONFIGS, source: SourceVolume, data: []byte(`{"app":"sailor"}`)}
+	raw.version = resourceVersion(nil, raw.data)
+	if err := consumer.applyResource(raw, "sailor resource reloaded"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 {
+		t.Errorf("expected only the initial load to notify got %+v", changes)
+	}
+}
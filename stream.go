@@ -0,0 +1,278 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+const (
+	// streamMinBackoff and streamMaxBackoff bound the wait before reconnecting
+	// to the watch endpoint after it failed
+	streamMinBackoff = 500 * time.Millisecond
+	streamMaxBackoff = 30 * time.Second
+
+	// streamMinPollInterval is the least time between the start of two long
+	// polls, a server answering right away must not make us spin
+	streamMinPollInterval = time.Second
+)
+
+// errStreamUnsupported is returned when Sailor does not serve the watch endpoint
+var errStreamUnsupported = errors.New("sailor does not support streaming")
+
+// streamEvent is what Sailor announces on the watch endpoint when a resource
+// changes, name is only set for misc resources
+type streamEvent struct {
+	Kind    opts.ResourceKind `json:"kind"`
+	Name    string            `json:"name"`
+	Version string            `json:"version"`
+}
+
+// watchStream keeps the STREAM resources up to date until the consumer is
+// closed. It holds a connection to the watch endpoint of the app and applies each
+// announced change, reconnecting with backoff when the connection drops. When
+// Sailor does not serve the watch endpoint the resources are pulled every
+// PullInterval instead.
+func (c *Consumer[C, S]) watchStream(resources []*opts.ResourceOption) {
+	backoff := streamMinBackoff
+	// every resource is pulled again when (re)connecting, changes may have
+	// happened while we were not connected
+	resync := true
+	for !c.closed() {
+		polled := time.Now()
+		connected, err := c.streamOnce(c.ctx, resources, resync)
+		if errors.Is(err, errStreamUnsupported) {
+			c.log().Warn("sailor watch endpoint unavailable, pulling resources instead", slog.Any("error", err))
+			for _, res := range resources {
+				go c.keepPullingResource(res)
+			}
+			return
+		}
+
+		if connected {
+			backoff = streamMinBackoff
+		}
+		if err == nil {
+			// long polling answered, ask again without the need to resync
+			resync = false
+			c.sleep(streamMinPollInterval - time.Since(polled))
+			continue
+		}
+		if c.closed() {
+			return
+		}
+		resync = true
+
+		c.log().Warn("sailor watch stream disconnected",
+			slog.Any("error", err),
+			slog.Duration("retry_in", backoff),
+		)
+		if !c.sleep(backoff) {
+			return
+		}
+		backoff = min(backoff*2, streamMaxBackoff)
+	}
+}
+
+// streamOnce opens the watch endpoint and consumes it until it ends. connected
+// tells if Sailor accepted the connection, which resets the reconnect backoff.
+// Server-sent events are applied as they arrive, a JSON response is treated as
+// a long poll answer carrying one or more events. With resync every resource
+// is pulled once connected, otherwise only the ones named in the events.
+func (c *Consumer[C, S]) streamOnce(ctx context.Context, resources []*opts.ResourceOption, resync bool) (connected bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.watchURL(resources), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream, application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return false, fmt.Errorf("%w: status %d", errStreamUnsupported, resp.StatusCode)
+	default:
+		return false, fmt.Errorf("watch endpoint: %w", c.statusError(resp.StatusCode))
+	}
+
+	if resync {
+		for _, res := range resources {
+			c.syncStreamResource(ctx, res, "")
+		}
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		// long poll timed out without any change
+		return true, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		events, err := decodeLongPoll(resp.Body)
+		if err != nil {
+			return true, err
+		}
+		for _, ev := range events {
+			c.applyStreamEvent(ctx, resources, ev)
+		}
+		return true, nil
+	}
+
+	err = readEventStream(resp.Body, func(ev streamEvent) {
+		c.applyStreamEvent(ctx, resources, ev)
+	})
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return true, err
+}
+
+// applyStreamEvent syncs the resource the event is about, events for resources
+// which are not streamed by this consumer are ignored
+func (c *Consumer[C, S]) applyStreamEvent(ctx context.Context, resources []*opts.ResourceOption, ev streamEvent) {
+	for _, res := range resources {
+		if res.Def.Kind == ev.Kind && res.Def.Name == ev.Name {
+			c.syncStreamResource(ctx, res, ev.Version)
+			return
+		}
+	}
+}
+
+// syncStreamResource pulls the resource unless the announced version is the one
+// already in use, an empty version always pulls
+func (c *Consumer[C, S]) syncStreamResource(ctx context.Context, res *opts.ResourceOption, version string) {
+	current := c.currentState(res.Def.Kind, res.Def.Name)
+	if version != "" && version == current.version {
+		return
+	}
+
+	raw, err := c.pullResource(ctx, res)
+	if err != nil {
+		return
+	}
+	raw.source = SourceStream
+
+	// nothing changed since it was loaded, unless it is served from fallback
+	if current.loaded && current.source != SourceFallback && current.version == raw.version {
+		return
+	}
+	c.applyResource(raw, "sailor resource reloaded")
+}
+
+// currentState is a copy of what the consumer knows about the resource
+func (c *Consumer[C, S]) currentState(kind opts.ResourceKind, name string) resourceState {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+	if st, ok := c.states[resourceKey{kind, name}]; ok {
+		return *st
+	}
+	return resourceState{}
+}
+
+// watchURL is the Sailor API endpoint announcing changes of the app resources.
+// Every streamed resource is listed with the version in use like in bulkURL,
+// config@v3 or misc/certs@v7, so Sailor answers right away about changes
+// published while no poll was open. A resource not loaded yet has no version.
+func (c *Consumer[C, S]) watchURL(resources []*opts.ResourceOption) string {
+	query := url.Values{}
+	for _, res := range resources {
+		name := string(res.Def.Kind)
+		if res.Def.Kind == opts.MISC {
+			name += "/" + res.Def.Name
+		}
+		if st := c.currentState(res.Def.Kind, res.Def.Name); st.loaded && st.version != "" {
+			name += "@" + st.version
+		}
+		query.Add("resource", name)
+	}
+
+	return fmt.Sprintf("%s/api/v1/watch/%s/%s?%s",
+		c.opts.Connection.Addr,
+		c.opts.Connection.Namespace,
+		c.opts.Connection.App,
+		query.Encode(),
+	)
+}
+
+// readEventStream reads server-sent events until r ends and calls fn for each
+// event carrying a change, comments and other fields are ignored
+func readEventStream(r io.Reader, fn func(streamEvent)) error {
+	scanner := bufio.NewScanner(r)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				var ev streamEvent
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &ev); err == nil {
+					fn(ev)
+				}
+				data = data[:0]
+			}
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+
+	return scanner.Err()
+}
+
+// decodeLongPoll reads a long poll answer which is either a single event or a
+// list of events
+func decodeLongPoll(r io.Reader) ([]streamEvent, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, nil
+	}
+
+	if b[0] == '[' {
+		var events []streamEvent
+		err := json.Unmarshal(b, &events)
+		return events, err
+	}
+
+	var ev streamEvent
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return nil, err
+	}
+	return []streamEvent{ev}, nil
+}
@@ -0,0 +1,309 @@
+package sailor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func TestStreamAppliesAnnouncedChanges(t *testing.T) {
+	type DummyConfig struct {
+		App string `json:"app"`
+	}
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", DummyConfig{App: "first"})
+	server.SetMisc("test", "test", "certs", []byte("first"))
+
+	consumer, err := NewConsumer[DummyConfig, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.Eventually(t, time.Second, func() bool { return server.Watchers("test", "test") == 1 })
+
+	server.SetConfig("test", "test", DummyConfig{App: "second"})
+	server.SetMisc("test", "test", "certs", []byte("second"))
+
+	sailortest.WaitForConfig(t, consumer, time.Second, func(c DummyConfig) bool { return c.App == "second" })
+	sailortest.WaitForMisc(t, consumer, "certs", time.Second, func(b []byte) bool { return string(b) == "second" })
+
+	// the initial pull, the sync once connected and the announced change, no
+	// interval pulling
+	if got := server.Requests("/api/v1/resource/test/test/config"); got > 3 {
+		t.Errorf("expected at most 3 config pulls got %d", got)
+	}
+
+	status := consumer.Status()
+	if status.Resources[0].Source != SourceStream || status.Resources[0].Version != "v2" {
+		t.Errorf("unexpected status %+v", status.Resources[0])
+	}
+}
+
+func TestStreamReconnects(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "first"})
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.Eventually(t, time.Second, func() bool { return server.Watchers("test", "test") == 1 })
+
+	// the change happens while the stream is down and is picked up on reconnect
+	server.CloseWatchers()
+	server.SetConfig("test", "test", map[string]string{"app": "second"})
+
+	sailortest.WaitForConfig(t, consumer, 3*time.Second, func(c map[string]string) bool { return c["app"] == "second" })
+	sailortest.Eventually(t, 3*time.Second, func() bool { return server.Watchers("test", "test") == 1 })
+}
+
+func TestStreamFallsBackToPulling(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetStreaming(false)
+	server.SetConfig("test", "test", map[string]string{"app": "first"})
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM, PullInterval: 5 * time.Millisecond},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetConfig("test", "test", map[string]string{"app": "second"})
+	sailortest.WaitForConfig(t, consumer, time.Second, func(c map[string]string) bool { return c["app"] == "second" })
+
+	if got := server.Requests("/api/v1/watch/test/test"); got != 1 {
+		t.Errorf("expected a single watch attempt got %d", got)
+	}
+}
+
+func TestStreamLongPoll(t *testing.T) {
+	var version, polls atomic.Int32
+	version.Store(1)
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/resource/test/test/config":
+			w.Header().Set("x-sailor-version", fmt.Sprintf("v%d", version.Load()))
+			fmt.Fprintf(w, `{"app":"v%d"}`, version.Load())
+		case "/api/v1/watch/test/test":
+			if polls.Add(1) > 1 {
+				// hold the poll until the test ends
+				<-done
+				return
+			}
+			version.Store(2)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"kind":"config","version":"v2"}]`)
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+			},
+		},
+		Connection: &opts.ConnectionOption{Addr: server.URL, Namespace: "test", App: "test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.WaitForConfig(t, consumer, time.Second, func(c map[string]string) bool { return c["app"] == "v2" })
+}
+
+func TestStreamLongPollSendsVersions(t *testing.T) {
+	var version, polls atomic.Int32
+	version.Store(1)
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/resource/test/test/config":
+			w.Header().Set("x-sailor-version", fmt.Sprintf("v%d", version.Load()))
+			fmt.Fprintf(w, `{"app":"v%d"}`, version.Load())
+		case "/api/v1/watch/test/test":
+			current := fmt.Sprintf("v%d", version.Load())
+			if polls.Add(1) == 1 {
+				// v2 is published after the first poll was answered and
+				// the config synced, while no poll is open
+				time.AfterFunc(200*time.Millisecond, func() { version.Store(2) })
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			// without the version in use the poll can only wait for the
+			// next change
+			_, known, ok := strings.Cut(r.URL.Query().Get("resource"), "@")
+			if !ok || known == current {
+				<-done
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `[{"kind":"config","version":"%s"}]`, current)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{{
+			Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+			FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+		}},
+		Connection: &opts.ConnectionOption{Addr: server.URL, Namespace: "test", App: "test"},
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.WaitForConfig(t, consumer, 3*time.Second, func(c map[string]string) bool { return c["app"] == "v2" })
+}
+
+func TestStreamLongPollPullsNamedResources(t *testing.T) {
+	var polls atomic.Int32
+	requests := map[string]*atomic.Int32{
+		"/api/v1/resource/test/test/config":     {},
+		"/api/v1/resource/test/test/misc/certs": {},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n, ok := requests[r.URL.Path]; ok {
+			n.Add(1)
+			w.Header().Set("x-sailor-version", fmt.Sprintf("v%d", polls.Load()))
+			fmt.Fprintf(w, `{"app":"v%d"}`, polls.Load())
+			return
+		}
+		if r.URL.Path != "/api/v1/watch/test/test" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// every poll is answered at once, the first one without any change
+		if polls.Add(1) == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"kind":"config","version":"v%d"}]`, polls.Load())
+	}))
+	defer server.Close()
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.STREAM},
+			},
+		},
+		Connection: &opts.ConnectionOption{Addr: server.URL, Namespace: "test", App: "test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	sailortest.WaitForConfig(t, consumer, 3*time.Second, func(c map[string]string) bool { return c["app"] == "v2" })
+
+	// polls answered at once are spaced out instead of spinning
+	if got := polls.Load(); got > 3 {
+		t.Errorf("expected polls to be spaced out, got %d", got)
+	}
+
+	// the initial load and the sync once connected, events name the config only
+	if got := requests["/api/v1/resource/test/test/misc/certs"].Load(); got != 2 {
+		t.Errorf("expected 2 misc pulls got %d", got)
+	}
+}
+
+func TestReadEventStream(t *testing.T) {
+	stream := ": connected\n\n" +
+		"event: change\ndata: {\"kind\":\"config\",\"version\":\"v2\"}\n\n" +
+		"data: {\"kind\":\"misc\",\n" +
+		"data: \"name\":\"certs\",\"version\":\"v3\"}\n\n" +
+		"data: not json\n\n"
+
+	var events []streamEvent
+	err := readEventStream(strings.NewReader(stream), func(ev streamEvent) { events = append(events, ev) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []streamEvent{
+		{Kind: opts.CONFIGS, Version: "v2"},
+		{Kind: opts.MISC, Name: "certs", Version: "v3"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %v got %v", want, events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("expected %v got %v", want[i], events[i])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// splitURIHosts takes the comma separated replicas out of the URI host, they
+// would not parse as a URL, and returns the URI with the first one only
+func splitURIHosts(uri string) (string, []string) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return uri, nil
+	}
+
+	authority, path := rest, ""
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+	userinfo, hosts := "", authority
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		userinfo, hosts = authority[:i+1], authority[i+1:]
+	}
+	if !strings.Contains(hosts, ",") {
+		return uri, nil
+	}
+
+	var first string
+	var extra []string
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		switch {
+		case host == "":
+		case first == "":
+			first = host
+		default:
+			extra = append(extra, host)
+		}
+	}
+
+	return scheme + "://" + userinfo + first + path, extra
+}
+
+// applyURIQuery maps the query options of a Sailor URI onto the connection,
+// unknown options are rejected so that a typo does not go unnoticed
+func applyURIQuery(conn *opts.ConnectionOption, query url.Values) error {
+	duration := func(key string) (time.Duration, error) {
+		d, err := time.ParseDuration(query.Get(key))
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("%w: %s=%q is not a positive duration", ErrInvalidURIOption, key, query.Get(key))
+		}
+		return d, nil
+	}
+
+	for key := range query {
+		var err error
+		switch key {
+		case "timeout":
+			conn.SocketTimeout, err = duration(key)
+		case "interval":
+			conn.PullInterval, err = duration(key)
+		case "env":
+			conn.Env = query.Get(key)
+		case "fallback":
+			conn.FallbackBaseURL = query.Get(key)
+		case "ca":
+			conn.CAFile = query.Get(key)
+		default:
+			err = fmt.Errorf("%w: unknown option %q", ErrInvalidURIOption, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// connect finishes building the consumer once its connection is known
+func (c *Consumer[C, S]) connect(initOpts opts.InitOption) (*Consumer[C, S], error) {
+	if err := checkSignatureOption(initOpts.Signature); err != nil {
+		return nil, err
+	}
+	if initOpts.EncryptedFallback && (initOpts.Connection.AccessKey == "" || initOpts.Connection.SecretKey == "") {
+		return nil, ErrPayloadNoCredentials
+	}
+
+	client, err := newSailorClient(initOpts.Connection)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval := initOpts.Connection.PullInterval; interval > 0 {
+		for i := range initOpts.Resources {
+			if initOpts.Resources[i].FetchDef.PullInterval == 0 {
+				initOpts.Resources[i].FetchDef.PullInterval = interval
+			}
+		}
+	}
+
+	endpoints, err := newEndpointPool(initOpts.Connection)
+	if err != nil {
+		return nil, err
+	}
+
+	c.opts = initOpts
+	c.sailorClient = client
+	c.endpoints = endpoints
+	c.breaker = newCircuitBreaker(initOpts.CircuitBreaker, c.circuitChanged)
+	c.reporter = newReporter(initOpts.Report)
+	if c.breaker != nil {
+		c.reportCircuit(CircuitClosed, time.Now())
+	}
+	return c, nil
+}
+
+// newSailorClient is the HTTP client talking to Sailor. SocketTimeout bounds
+// connecting and the TLS handshake only, as watch streams stay open for long.
+func newSailorClient(conn *opts.ConnectionOption) (*http.Client, error) {
+	if conn.SocketTimeout == 0 && conn.CAFile == "" {
+		return &http.Client{}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if conn.SocketTimeout > 0 {
+		dialer := &net.Dialer{Timeout: conn.SocketTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = conn.SocketTimeout
+	}
+
+	if conn.CAFile != "" {
+		pem, err := os.ReadFile(conn.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrInvalidCAFile, conn.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w %s: no PEM certificates found", ErrInvalidCAFile, conn.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// fallbackBaseURL is where fallback resources are fetched from, the
+// environment wins over the connection
+func (c *Consumer[C, S]) fallbackBaseURL() string {
+	if base := os.Getenv(ENV_SAILOR_FALLBACK_BASE_URL); base != "" {
+		return base
+	}
+
+	if c.opts.Connection != nil {
+		return c.opts.Connection.FallbackBaseURL
+	}
+
+	return ""
+}
@@ -0,0 +1,53 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+)
+
+// pinnedStatusError explains a response other than 200, for a resource pinned
+// to a version a 404 or 410 means Sailor cannot serve that version anymore
+func (c *Consumer[C, S]) pinnedStatusError(res *opts.ResourceOption, statusCode int) error {
+	if res.Def.Version != "" && (statusCode == http.StatusNotFound || statusCode == http.StatusGone) {
+		return fmt.Errorf("%w: %s with status %d", ErrVersionUnavailable, res.Def.Version, statusCode)
+	}
+	return c.statusError(statusCode)
+}
+
+// servedPinnedVersion checks that Sailor served the pinned version, a server
+// which does not announce versions is trusted
+func servedPinnedVersion(res *opts.ResourceOption, header http.Header) error {
+	if res.Def.Version == "" {
+		return nil
+	}
+	if served := header.Get(headerSailorVersion); served != "" && served != res.Def.Version {
+		return fmt.Errorf("%w: pinned %s, sailor served %s", ErrVersionMismatch, res.Def.Version, served)
+	}
+	return nil
+}
+
+// pinnedResourceVersion is the version of a fetched resource, the pinned one
+// when Sailor does not announce it
+func pinnedResourceVersion(res *opts.ResourceOption, header http.Header, data []byte) string {
+	if res.Def.Version != "" && header.Get(headerSailorVersion) == "" {
+		return res.Def.Version
+	}
+	return resourceVersion(header, data)
+}
@@ -0,0 +1,176 @@
+package sailor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/metrics"
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+)
+
+func pinnedConfig(fetch opts.FetchOption, version string) opts.ResourceOption {
+	return opts.ResourceOption{
+		Def:      opts.ResourceDefinition{Kind: opts.CONFIGS, Version: version},
+		FetchDef: opts.FetchDefinition{Fetch: fetch, PullInterval: 20 * time.Millisecond},
+	}
+}
+
+func TestPinnedVersionPull(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "canary"})
+	server.SetConfig("test", "test", map[string]string{"release": "latest"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{pinnedConfig(opts.PULL, "v1")},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "canary")
+
+	// background pulls keep the pinned version whatever gets published
+	server.SetConfig("test", "test", map[string]string{"release": "newer"})
+	time.Sleep(100 * time.Millisecond)
+	assertRelease(t, consumer, "canary")
+	if st := consumer.Status().Resources[0]; st.Version != "v1" || st.LastError != "" {
+		t.Errorf("expected v1 to stay in use without errors, got %+v", st)
+	}
+
+	// once Sailor cannot serve v1 anymore the value is kept and the mismatch
+	// reported
+	server.ForgetVersion("test", "test", opts.CONFIGS, "", 1)
+	time.Sleep(100 * time.Millisecond)
+	assertRelease(t, consumer, "canary")
+	if st := consumer.Status().Resources[0]; !strings.Contains(st.LastError, ErrVersionUnavailable.Error()) {
+		t.Errorf("expected the unavailable version to be reported, got %+v", st)
+	}
+}
+
+func TestPinnedVersionStaysFresh(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "canary"})
+	server.SetConfig("test", "test", map[string]string{"release": "latest"})
+
+	res := pinnedConfig(opts.PULL, "v1")
+	res.MaxStaleness = 60 * time.Millisecond
+	registry := metrics.NewRegistry()
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{res},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+		Metrics:    registry,
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// every pull of the pinned version is an attempt which confirms it
+	time.Sleep(150 * time.Millisecond)
+	if st := consumer.Status().Resources[0]; !st.Ready || st.Version != "v1" {
+		t.Errorf("expected the pinned version to stay fresh, got %+v", st)
+	}
+	snap := registry.Snapshot()[0]
+	if snap.FetchAttempts["pull"] < 3 || snap.HTTPStatuses[http.StatusOK] < 3 {
+		t.Errorf("expected the pulls to be counted, got %+v", snap)
+	}
+	if age := time.Since(snap.LastSuccess); age > res.MaxStaleness {
+		t.Errorf("expected a recent last success, got %s ago", age)
+	}
+}
+
+func TestPinnedVersionUnavailableAtStart(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "latest"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{pinnedConfig(opts.PULL, "v7")},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	err := consumer.Start()
+	if !errors.Is(err, ErrVersionUnavailable) {
+		t.Fatalf("expected ErrVersionUnavailable, got %v", err)
+	}
+
+	var re *ResourceError
+	if !errors.As(err, &re) || re.Stage != StageStatus || re.StatusCode != http.StatusGone {
+		t.Errorf("unexpected resource error %+v", re)
+	}
+}
+
+func TestPinnedVersionMismatch(t *testing.T) {
+	// a server which does not know about pinning serves the latest version
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerSailorVersion, "v9")
+		w.Write([]byte(`{"release": "latest"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	conn := &opts.ConnectionOption{Addr: server.URL, Namespace: "test", App: "test", AccessKey: "ak", SecretKey: "sk"}
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{pinnedConfig(opts.PULL, "v1")},
+		Connection: conn,
+	})
+	err := consumer.Start()
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+
+	var re *ResourceError
+	if !errors.As(err, &re) || re.Stage != StageVersion {
+		t.Errorf("unexpected resource error %+v", re)
+	}
+}
+
+func TestPinnedVersionBulk(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "canary"})
+	server.SetConfig("test", "test", map[string]string{"release": "latest"})
+	server.SetMisc("test", "test", "certs", []byte("certs"))
+
+	config := pinnedConfig(opts.PULL, "v1")
+	config.FetchDef.Once = true
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources: []opts.ResourceOption{config, {
+			Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+			FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+		}},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	assertRelease(t, consumer, "canary")
+	if n := server.Requests("/api/v1/resource/test/test/config"); n != 0 {
+		t.Errorf("expected the pinned version to come from the bulk endpoint, got %d requests", n)
+	}
+}
+
+func TestPinnedVersionDev(t *testing.T) {
+	home := t.TempDir()
+	overrideHome(t, home)
+
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"release": "canary"})
+	server.SetConfig("test", "test", map[string]string{"release": "latest"})
+
+	consumer := newTestConsumer[map[string]string, any](t, opts.InitOption{
+		Resources:  []opts.ResourceOption{pinnedConfig(opts.DEV, "v1")},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+	assertRelease(t, consumer, "canary")
+
+	matches, _ := filepath.Glob(filepath.Join(home, ".sailor", "cache", "*@v1.json"))
+	if len(matches) != 1 {
+		t.Errorf("expected the pinned version to be cached on its own, got %v", matches)
+	}
+}
@@ -0,0 +1,172 @@
+// sailor-go
+// Copyright (C) 2025 SailorHQ and Ashish Shekar (codekidX)
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package sailor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/signing"
+)
+
+const (
+	// webhookTolerance is how far the webhook timestamp may be from our clock,
+	// nonces are remembered for twice as long
+	webhookTolerance = 5 * time.Minute
+
+	// maxWebhookBody bounds the size of a change notification
+	maxWebhookBody = 64 << 10
+)
+
+// webhookNonces remembers the nonces seen within the tolerance window
+type webhookNonces struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// use records the nonce and reports false if it was already used
+func (n *webhookNonces) use(nonce string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.seen == nil {
+		n.seen = map[string]time.Time{}
+	}
+	for k, expires := range n.seen {
+		if now.After(expires) {
+			delete(n.seen, k)
+		}
+	}
+
+	if _, ok := n.seen[nonce]; ok {
+		return false
+	}
+	n.seen[nonce] = now.Add(2 * webhookTolerance)
+	return true
+}
+
+// verifyWebhook checks the signature, the freshness and the nonce of a change
+// notification, signed by Sailor with the app keys like any request to Sailor
+func (c *Consumer[C, S]) verifyWebhook(r *http.Request) error {
+	conn := c.opts.Connection
+	if conn == nil || conn.AccessKey == "" || conn.SecretKey == "" {
+		return ErrWebhookBadSignature
+	}
+
+	verifier := signing.Verifier{
+		SecretKey: signing.StaticKeys(map[string]string{conn.AccessKey: conn.SecretKey}),
+		MaxSkew:   webhookTolerance,
+		UseNonce:  c.webhookNonces.use,
+	}
+	_, err := verifier.Verify(r)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, signing.ErrExpired):
+		return ErrWebhookStale
+	case errors.Is(err, signing.ErrReplayed):
+		return ErrWebhookReplayed
+	case errors.As(err, new(*http.MaxBytesError)):
+		return err
+	}
+	return ErrWebhookBadSignature
+}
+
+// WebhookHandler receives change notifications pushed by Sailor and refreshes
+// just the resource they name. A notification is a POST with a JSON body of
+// {"kind", "name", "version"} signed with the app keys as described in the
+// signing package.
+//
+// Notifications older or newer than 5 minutes and reused nonces are rejected
+// with 401. A notification for a version already in use is acknowledged without
+// refreshing.
+func (c *Consumer[C, S]) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBody)
+		if err := c.verifyWebhook(r); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "cannot read body", http.StatusBadRequest)
+				return
+			}
+			c.log().Warn("sailor webhook rejected", slog.Any("error", err))
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "cannot read body", http.StatusBadRequest)
+			return
+		}
+
+		var ev streamEvent
+		if err := json.Unmarshal(body, &ev); err != nil || ev.Kind == "" {
+			http.Error(w, "body must be a change notification", http.StatusBadRequest)
+			return
+		}
+
+		if ev.Version != "" && ev.Version == c.currentState(ev.Kind, ev.Name).version {
+			writeRefreshResult(w, http.StatusOK, RefreshResult{Kind: ev.Kind, Name: ev.Name, Version: ev.Version})
+			return
+		}
+
+		// Sailor going away must not abort the refresh half way
+		result, err := c.Refresh(context.WithoutCancel(r.Context()), ev.Kind, ev.Name)
+		switch {
+		case errors.Is(err, ErrResourceNotManaged):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			writeRefreshResult(w, http.StatusBadGateway, result)
+		default:
+			writeRefreshResult(w, http.StatusOK, result)
+		}
+	})
+}
+
+func writeRefreshResult(w http.ResponseWriter, code int, result RefreshResult) {
+	body := struct {
+		Kind    string `json:"kind"`
+		Name    string `json:"name,omitempty"`
+		Source  Source `json:"source,omitempty"`
+		Version string `json:"version,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}{
+		Kind:    string(result.Kind),
+		Name:    result.Name,
+		Source:  result.Source,
+		Version: result.Version,
+	}
+	if result.Err != nil {
+		body.Error = result.Err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,139 @@
+package sailor
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sailorhq/sailor-go/pkg/opts"
+	"github.com/sailorhq/sailor-go/pkg/sailortest"
+	"github.com/sailorhq/sailor-go/pkg/signing"
+)
+
+// webhookRequest is a notification signed by Sailor for the ak access key, an
+// empty nonce is left out of the request
+func webhookRequest(secretKey string, timestamp time.Time, nonce, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/sailor/webhook", strings.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(body)), nil }
+	req.Header.Set(signing.HeaderNonce, nonce)
+	if err := signing.Sign(req, "ak", secretKey, timestamp); err != nil {
+		panic(err)
+	}
+	if nonce == "" {
+		req.Header.Del(signing.HeaderNonce)
+	}
+	return req
+}
+
+func serveWebhook(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookRefreshesResource(t *testing.T) {
+	server := sailortest.NewServer(t)
+	server.SetConfig("test", "test", map[string]string{"app": "first"})
+	server.SetMisc("test", "test", "certs", []byte("first"))
+
+	consumer, err := NewConsumer[map[string]string, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.MISC, Name: "certs"},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: server.Connection("test", "test", "ak", "sk"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	if err := consumer.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetConfig("test", "test", map[string]string{"app": "second"})
+	server.SetMisc("test", "test", "certs", []byte("second"))
+	handler := consumer.WebhookHandler()
+
+	rec := serveWebhook(handler, webhookRequest("sk", time.Now(), "n1", `{"kind":"config","version":"v2"}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d %s", rec.Code, rec.Body)
+	}
+
+	config, _ := consumer.Get()
+	if config["app"] != "second" {
+		t.Errorf("expected second got %s", config["app"])
+	}
+
+	// only the named resource is refreshed
+	misc, _ := consumer.GetMisc("certs")
+	if string(misc) != "first" {
+		t.Errorf("expected misc to stay first got %s", misc)
+	}
+
+	// an already applied version does not hit Sailor again
+	pulls := server.Requests("/api/v1/resource/test/test/config")
+	rec = serveWebhook(handler, webhookRequest("sk", time.Now(), "n2", `{"kind":"config","version":"v2"}`))
+	if rec.Code != http.StatusOK || server.Requests("/api/v1/resource/test/test/config") != pulls {
+		t.Errorf("expected no refresh for the version in use, got %d", rec.Code)
+	}
+
+	rec = serveWebhook(handler, webhookRequest("sk", time.Now(), "n3", `{"kind":"misc","name":"other"}`))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unmanaged resource got %d", rec.Code)
+	}
+}
+
+func TestWebhookRejectsUntrustedRequests(t *testing.T) {
+	consumer, err := NewConsumer[any, any](opts.InitOption{
+		Resources: []opts.ResourceOption{
+			{
+				Def:      opts.ResourceDefinition{Kind: opts.CONFIGS},
+				FetchDef: opts.FetchDefinition{Fetch: opts.PULL, Once: true},
+			},
+		},
+		Connection: &opts.ConnectionOption{Addr: "http://localhost:7766", Namespace: "test", App: "test", AccessKey: "ak", SecretKey: "sk"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+	handler := consumer.WebhookHandler()
+	body := `{"kind":"misc","name":"other"}`
+
+	tampered := webhookRequest("sk", time.Now(), "tampered", body)
+	tampered.Body = http.NoBody
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"wrong key", webhookRequest("other", time.Now(), "a", body), http.StatusUnauthorized},
+		{"tampered body", tampered, http.StatusUnauthorized},
+		{"stale", webhookRequest("sk", time.Now().Add(-10*time.Minute), "b", body), http.StatusUnauthorized},
+		{"from the future", webhookRequest("sk", time.Now().Add(10*time.Minute), "c", body), http.StatusUnauthorized},
+		{"no nonce", webhookRequest("sk", time.Now(), "", body), http.StatusUnauthorized},
+		{"wrong method", httptest.NewRequest(http.MethodGet, "/sailor/webhook", nil), http.StatusMethodNotAllowed},
+		{"valid", webhookRequest("sk", time.Now(), "d", body), http.StatusNotFound},
+		{"replayed", webhookRequest("sk", time.Now(), "d", body), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveWebhook(handler, tt.req); rec.Code != tt.code {
+				t.Errorf("expected %d got %d %s", tt.code, rec.Code, rec.Body)
+			}
+		})
+	}
+}